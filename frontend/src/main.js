@@ -2,10 +2,13 @@ import './style.css';
 
 import {
   LoadProfiles, SaveProfile, DeleteProfile,
-  StartTimer, ResumeTimer, PauseTimer, StopTimer, GetTimerState,
-  PlayLooping, PlayShuffleFolder, StopAudio, SetVolume, GetAudioState,
-  CheckResumeSession, PickMusicFile, PickMusicFolder,
-  GetSettings, SaveSettings,
+  StartProfile, SkipSegment, ResumeTimer, PauseTimer, UnpauseTimer, StopTimer, GetTimerState,
+  PlaySegmentAudio, StopAudio, PauseAudio, ResumeAudio, NextTrack, PreviousTrack,
+  GetTrackPrefs, SetTrackPrefs, BanTrack, GetCoverArt, GetBadFiles, ClearBadFiles,
+  SetVolume, SetMuffle, GetAudioState,
+  CheckResumeSession, PickMusicFile, PickMusicFolder, ListNoiseKinds,
+  LoadSoundscapes, SetLayerVolume, ListTonePresets,
+  GetSettings, SaveSettings, ListChimes, PreviewChime, ListAudioDevices,
   GetStats, RecordSessionComplete
 } from '../wailsjs/go/app/App';
 
@@ -29,8 +32,15 @@ const resumeBtn      = document.getElementById('resumeBtn');
 const audioDot       = document.getElementById('audioDot');
 const trackNameEl    = document.getElementById('trackName');
 const audioSubEl     = document.getElementById('audioSub');
+const audioCoverEl   = document.getElementById('audioCover');
+const audioTimeEl    = document.getElementById('audioTime');
 const volumeSlider   = document.getElementById('volumeSlider');
 const muteBtn        = document.getElementById('muteBtn');
+const prevTrackBtn   = document.getElementById('prevTrackBtn');
+const nextTrackBtn   = document.getElementById('nextTrackBtn');
+const favTrackBtn    = document.getElementById('favTrackBtn');
+const banTrackBtn    = document.getElementById('banTrackBtn');
+const muffleBtn      = document.getElementById('muffleBtn');
 
 // Profile panel
 const overlay        = document.getElementById('overlay');
@@ -42,10 +52,17 @@ const pfName         = document.getElementById('pfName');
 const pfDuration     = document.getElementById('pfDuration');
 const pfMusicPath    = document.getElementById('pfMusicPath');
 const pfShuffle      = document.getElementById('pfShuffle');
+const pfScanDepth    = document.getElementById('pfScanDepth');
+const pfInclude      = document.getElementById('pfInclude');
+const pfExclude      = document.getElementById('pfExclude');
 const pfEditId       = document.getElementById('pfEditId');
 const pfBreakDuration  = document.getElementById('pfBreakDuration');
 const pfBreakMusicPath = document.getElementById('pfBreakMusicPath');
 const pfBreakShuffle   = document.getElementById('pfBreakShuffle');
+const pfWorkVolume     = document.getElementById('pfWorkVolume');
+const pfBreakVolume    = document.getElementById('pfBreakVolume');
+const pfWorkEQ         = document.getElementById('pfWorkEQ');
+const pfBreakEQ        = document.getElementById('pfBreakEQ');
 const pfIsDefault      = document.getElementById('pfIsDefault');
 const modeBadge        = document.getElementById('modeBadge');
 
@@ -64,6 +81,14 @@ const stAutoAudio    = document.getElementById('stAutoAudio');
 const stNotify       = document.getElementById('stNotify');
 const stAutoNext     = document.getElementById('stAutoNext');
 const stTheme        = document.getElementById('stTheme');
+const stPlaySound    = document.getElementById('stPlaySound');
+const stChime        = document.getElementById('stChime');
+const stChimeVolume  = document.getElementById('stChimeVolume');
+const stFadeIn       = document.getElementById('stFadeIn');
+const stCrossfade    = document.getElementById('stCrossfade');
+const stFadeOut      = document.getElementById('stFadeOut');
+const stNormalize    = document.getElementById('stNormalize');
+const stDevice       = document.getElementById('stDevice');
 const settingsSaved  = document.getElementById('settingsSaved');
 
 // ── App state ─────────────────────────────────────────────────────────────────
@@ -72,6 +97,7 @@ let settings     = {};
 let totalSec     = 25 * 60;
 let remainSec    = totalSec;
 let isRunning    = false;
+let isPaused     = false;  // session paused mid-segment (Start continues it)
 let savedSession  = null;
 let sessionType   = 'work'; // 'work' | 'break'
 let activeProfile = null;   // currently running profile
@@ -92,8 +118,14 @@ function updateTimerUI(remaining, total) {
   fillEl.style.width  = pct + '%';
 }
 
+// Length of a profile's first segment (custom sequences override durationSec).
+function firstSegmentSec(p) {
+  return p.segments && p.segments.length ? p.segments[0].durationSec : p.durationSec;
+}
+
 function setRunningUI(running) {
   isRunning = running;
+  if (running) isPaused = false;
   startBtn.textContent       = running ? 'Pause' : 'Start';
   startBtn.style.background  = running ? 'rgba(255,180,50,0.55)' : '';
   miniPlayPause.textContent  = running ? '\u23F8' : '\u25B6';
@@ -114,6 +146,7 @@ overlay.addEventListener('click', closeAllPanels);
 document.getElementById('openProfiles').addEventListener('click', () => openPanel(profilePanel));
 document.getElementById('openSettings').addEventListener('click', async () => {
   await loadSettingsIntoForm();
+  renderBadFiles();
   openPanel(settingsPanel);
 });
 document.getElementById('closeProfiles').addEventListener('click', closeAllPanels);
@@ -125,7 +158,7 @@ function renderProfileList() {
     <div class="profile-item${p.isDefault ? ' is-default' : ''}">
       <div class="profile-item-info">
         <div class="profile-item-name">${p.isDefault ? '<span class="default-star" title="Default">★</span> ' : ''}${escHtml(p.name)}</div>
-        <div class="profile-item-meta">${Math.floor(p.durationSec/60)} min${p.breakDurationSec > 0 ? ' + ' + Math.floor(p.breakDurationSec/60) + 'm break' : ''}${p.musicPath ? ' · ' + (p.shuffle ? 'Shuffle' : 'Loop') : ''}</div>
+        <div class="profile-item-meta">${p.segments && p.segments.length ? p.segments.length + ' segment sequence' : Math.floor(p.durationSec/60) + ' min' + (p.breakDurationSec > 0 ? ' + ' + Math.floor(p.breakDurationSec/60) + 'm break' : '')}${p.musicPath ? ' · ' + (p.shuffle ? 'Shuffle' : 'Loop') : ''}</div>
       </div>
       <div class="profile-item-actions">
         <button class="item-btn" data-id="${p.id}" data-action="edit">Edit</button>
@@ -155,7 +188,7 @@ function refreshDropdown(selectDefault = false) {
     profileSelect.value = prev;
   }
   const sel = profiles.find(p => p.id === profileSelect.value) || profiles[0];
-  if (sel) { totalSec = remainSec = sel.durationSec; updateTimerUI(remainSec, totalSec); }
+  if (sel) { totalSec = remainSec = firstSegmentSec(sel); updateTimerUI(remainSec, totalSec); }
 }
 
 function escHtml(s) {
@@ -174,11 +207,18 @@ function openNewForm() {
   pfDuration.value           = '25';
   pfMusicPath.value          = '';
   pfShuffle.checked          = false;
+  pfScanDepth.value          = '';
+  pfInclude.value            = '';
+  pfExclude.value            = '';
   pfBreakDuration.value      = '0';
   pfBreakMusicPath.value     = '';
   pfBreakMusicPath.dataset.sentinel = '';
   pfBreakMusicPath.classList.remove('is-none');
   pfBreakShuffle.checked     = false;
+  pfWorkVolume.value         = '';
+  pfBreakVolume.value        = '';
+  setToneSelect(pfWorkEQ, {});
+  setToneSelect(pfBreakEQ, {});
   pfIsDefault.checked        = false;
   pfEditId.value             = '';
   showForm(true);
@@ -193,11 +233,18 @@ function openEditForm(id) {
   pfDuration.value           = Math.floor(p.durationSec / 60).toString();
   pfMusicPath.value          = p.musicPath || '';
   pfShuffle.checked          = !!p.shuffle;
+  pfScanDepth.value          = p.scan?.maxDepth ? String(p.scan.maxDepth) : '';
+  pfInclude.value            = (p.scan?.include || []).join(', ');
+  pfExclude.value            = (p.scan?.exclude || []).join(', ');
   pfBreakDuration.value      = Math.floor((p.breakDurationSec || 0) / 60).toString();
   pfBreakMusicPath.value     = p.breakMusicPath === '__none__' ? 'No music' : (p.breakMusicPath || '');
   pfBreakMusicPath.dataset.sentinel = p.breakMusicPath === '__none__' ? '__none__' : '';
   pfBreakMusicPath.classList.toggle('is-none', p.breakMusicPath === '__none__');
   pfBreakShuffle.checked     = !!p.breakShuffle;
+  pfWorkVolume.value         = p.workVolume ? String(p.workVolume) : '';
+  pfBreakVolume.value        = p.breakVolume ? String(p.breakVolume) : '';
+  setToneSelect(pfWorkEQ, p.workEq);
+  setToneSelect(pfBreakEQ, p.breakEq);
   pfIsDefault.checked        = !!p.isDefault;
   pfEditId.value             = p.id;
   showForm(true);
@@ -216,6 +263,47 @@ document.getElementById('pickFolder').addEventListener('click', async () => {
   if (path) { pfMusicPath.value = path; pfShuffle.checked = true; }
 });
 
+// Internet radio is stored as its http(s) URL
+function askStreamURL(current) {
+  const url = (prompt('Stream URL (MP3 or Ogg, e.g. an Icecast station):', /^https?:\/\//i.test(current) ? current : '') || '').trim();
+  return /^https?:\/\/\S+$/i.test(url) ? url : '';
+}
+
+document.getElementById('pickURL').addEventListener('click', () => {
+  const url = askStreamURL(pfMusicPath.value);
+  if (url) { pfMusicPath.value = url; pfShuffle.checked = false; }
+});
+
+// Generated noise is stored as a "noise:<kind>" music path, tones as
+// "tone:<mode>:<carrier>:<beat>:<wave>" and a soundscape as "soundscape:<id>"
+const pickNoise      = document.getElementById('pickNoise');
+const pickBreakNoise = document.getElementById('pickBreakNoise');
+ListNoiseKinds().then(kinds => {
+  const opts = kinds.map(k => `<option value="noise:${k}">${k[0].toUpperCase() + k.slice(1)}</option>`).join('');
+  pickNoise.querySelector('optgroup[label="Noise"]').insertAdjacentHTML('beforeend', opts);
+  pickBreakNoise.querySelector('optgroup[label="Noise"]').insertAdjacentHTML('beforeend', opts);
+}).catch(() => {});
+ListTonePresets().then(presets => {
+  const opts = (presets || []).map(p => `<option value="${escHtml(p.source)}">${escHtml(p.name)}</option>`).join('');
+  pickNoise.querySelector('optgroup[label="Tones"]').insertAdjacentHTML('beforeend', opts);
+  pickBreakNoise.querySelector('optgroup[label="Tones"]').insertAdjacentHTML('beforeend', opts);
+}).catch(() => {});
+LoadSoundscapes().then(scapes => {
+  const opts = (scapes || []).map(sc => `<option value="soundscape:${escHtml(sc.id)}">${escHtml(sc.name)}</option>`).join('');
+  pickNoise.querySelector('optgroup[label="Soundscapes"]').insertAdjacentHTML('beforeend', opts);
+  pickBreakNoise.querySelector('optgroup[label="Soundscapes"]').insertAdjacentHTML('beforeend', opts);
+}).catch(() => {});
+
+pickNoise.addEventListener('change', () => {
+  if (pickNoise.value) { pfMusicPath.value = pickNoise.value; pfShuffle.checked = false; }
+  pickNoise.value = '';
+});
+
+pickBreakNoise.addEventListener('change', () => {
+  if (pickBreakNoise.value) { pfBreakMusicPath.value = pickBreakNoise.value; pfBreakMusicPath.dataset.sentinel = ''; pfBreakMusicPath.classList.remove('is-none'); pfBreakShuffle.checked = false; }
+  pickBreakNoise.value = '';
+});
+
 document.getElementById('clearMusic').addEventListener('click', () => {
   pfMusicPath.value = '';
   pfShuffle.checked = false;
@@ -231,6 +319,11 @@ document.getElementById('pickBreakFolder').addEventListener('click', async () =>
   if (path) { pfBreakMusicPath.value = path; pfBreakMusicPath.dataset.sentinel = ''; pfBreakMusicPath.classList.remove('is-none'); pfBreakShuffle.checked = true; }
 });
 
+document.getElementById('pickBreakURL').addEventListener('click', () => {
+  const url = askStreamURL(pfBreakMusicPath.value);
+  if (url) { pfBreakMusicPath.value = url; pfBreakMusicPath.dataset.sentinel = ''; pfBreakMusicPath.classList.remove('is-none'); pfBreakShuffle.checked = false; }
+});
+
 document.getElementById('breakMusicNone').addEventListener('click', () => {
   pfBreakMusicPath.value = 'No music';
   pfBreakMusicPath.dataset.sentinel = '__none__';
@@ -245,13 +338,49 @@ document.getElementById('clearBreakMusic').addEventListener('click', () => {
   pfBreakShuffle.checked = false;
 });
 
+function splitPatterns(text) {
+  return text.split(',').map(s => s.trim()).filter(Boolean);
+}
+
+// Phase volume from the form: 1–100, or 0 (full master volume) when left empty
+function clampVolume(text) {
+  const v = parseInt(text, 10);
+  return v > 0 ? Math.min(v, 100) : 0;
+}
+
+// Tone presets for the phase EQ; an EQ edited in profiles.json shows as
+// "Custom" and is kept as it is
+const TONES = {
+  full:    { name: 'Full',    eq: {} },
+  soft:    { name: 'Soft',    eq: { lowPassHz: 6000, trebleDb: -4 } },
+  warm:    { name: 'Warm',    eq: { bassDb: 3, trebleDb: -6 } },
+  muffled: { name: 'Muffled', eq: { lowPassHz: 800, highPassHz: 60 } },
+};
+function toneKey(eq) {
+  const json = JSON.stringify(eq || {});
+  return Object.keys(TONES).find(k => JSON.stringify(TONES[k].eq) === json) || 'custom';
+}
+function setToneSelect(select, eq) {
+  const key = toneKey(eq);
+  select.innerHTML = Object.entries(TONES).map(([k, t]) => `<option value="${k}">${t.name}</option>`).join('')
+    + (key === 'custom' ? '<option value="custom">Custom</option>' : '');
+  select.value = key;
+}
+function toneFromSelect(select, eq) {
+  return select.value === 'custom' ? eq : { ...TONES[select.value].eq };
+}
+
 document.getElementById('saveProfileBtn').addEventListener('click', async () => {
   const name      = pfName.value.trim();
   if (!name) { pfName.focus(); return; }
   const dur       = Math.max(1, parseInt(pfDuration.value, 10) || 25);
   const breakMins = Math.max(0, parseInt(pfBreakDuration.value, 10) || 0);
   const id        = pfEditId.value || ('p' + Date.now());
+  const existing = profiles.find(x => x.id === id);
+  // Spread the existing profile so fields without a control here (segments,
+  // pause limits, warnings) survive an edit
   const p = {
+    ...(existing || {}),
     id,
     name,
     durationSec:      dur * 60,
@@ -260,7 +389,16 @@ document.getElementById('saveProfileBtn').addEventListener('click', async () =>
     breakDurationSec: breakMins * 60,
     breakMusicPath:   pfBreakMusicPath.dataset.sentinel === '__none__' ? '__none__' : pfBreakMusicPath.value.trim(),
     breakShuffle:     !!pfBreakShuffle.checked,
+    workVolume:       clampVolume(pfWorkVolume.value),
+    breakVolume:      clampVolume(pfBreakVolume.value),
+    workEq:           toneFromSelect(pfWorkEQ, existing?.workEq),
+    breakEq:          toneFromSelect(pfBreakEQ, existing?.breakEq),
     isDefault:        !!pfIsDefault.checked,
+    scan: {
+      maxDepth: parseInt(pfScanDepth.value, 10) || 0,
+      include:  splitPatterns(pfInclude.value),
+      exclude:  splitPatterns(pfExclude.value),
+    },
   };
   await SaveProfile(p).catch(console.error);
   // If marked as default, clear isDefault on all others in local cache
@@ -292,16 +430,65 @@ async function loadSettingsIntoForm() {
     stNotify.checked       = !!settings.notifyOnComplete;
     stAutoNext.checked     = !!settings.autoStartNextTimer;
     stTheme.value          = settings.theme || 'dark';
+    const chimes           = await ListChimes().catch(() => []);
+    stChime.innerHTML      = chimes.map(c => `<option value="${escHtml(c)}">${escHtml(c)}</option>`).join('');
+    stPlaySound.checked    = !!settings.playSoundOnComplete;
+    stChime.value          = settings.completionChime || chimes[0] || '';
+    stChimeVolume.value    = settings.chimeVolume ?? 80;
+    stFadeIn.value         = settings.fadeInSec ?? 3;
+    stCrossfade.value      = settings.crossfadeSec ?? 4;
+    stFadeOut.value        = settings.fadeOutSec ?? 5;
+    stNormalize.checked    = !!settings.normalizeLoudness;
+    // Where the device cannot be chosen (Windows, macOS, Linux without a sound
+    // server) the list fails; say why and offer only the system default
+    let devices = [{ id: '', name: 'System default' }], deviceErr = '';
+    try { devices = await ListAudioDevices(); } catch (err) { deviceErr = String(err); }
+    stDevice.innerHTML     = devices.map(d =>
+      `<option value="${escHtml(d.id)}">${escHtml(d.name)}${d.default ? ' (default)' : ''}</option>`).join('');
+    stDevice.disabled      = !!deviceErr;
+    document.getElementById('stDeviceDesc').textContent =
+      deviceErr ? deviceErr.charAt(0).toUpperCase() + deviceErr.slice(1) : 'Falls back to the default when unplugged';
+    // A saved device that is unplugged stays selected, so it is used again once it is back
+    if (!deviceErr && settings.audioDevice && !devices.some(d => d.id === settings.audioDevice)) {
+      stDevice.insertAdjacentHTML('beforeend',
+        `<option value="${escHtml(settings.audioDevice)}">${escHtml(settings.audioDevice)} (not connected)</option>`);
+    }
+    stDevice.value         = settings.audioDevice || '';
   } catch (e) { console.error('GetSettings failed', e); }
 }
 
+// Files the backend could not decode; Retry lets them play again
+async function renderBadFiles() {
+  const files = await GetBadFiles().catch(() => []);
+  document.getElementById('badFilesRow').style.display = files.length ? '' : 'none';
+  document.getElementById('badFilesList').innerHTML = files.map(f =>
+    `<li title="${escHtml(f.error)}">${escHtml(f.path.split(/[\\/]/).pop())}</li>`).join('');
+}
+document.getElementById('retryBadFilesBtn').addEventListener('click', async () => {
+  await ClearBadFiles().catch(console.error);
+  renderBadFiles();
+});
+
+stChime.addEventListener('change', () => PreviewChime(stChime.value, parseInt(stChimeVolume.value, 10)).catch(() => {}));
+stChimeVolume.addEventListener('change', () => PreviewChime(stChime.value, parseInt(stChimeVolume.value, 10)).catch(() => {}));
+
 document.getElementById('saveSettingsBtn').addEventListener('click', async () => {
+  // Spread the loaded settings so fields without a control here are kept
   const s = {
+    ...settings,
     defaultVolume:      parseInt(stVolume.value, 10),
     autoStartAudio:     stAutoAudio.checked,
     notifyOnComplete:   stNotify.checked,
     autoStartNextTimer: stAutoNext.checked,
     theme:              stTheme.value || 'dark',
+    playSoundOnComplete: stPlaySound.checked,
+    completionChime:     stChime.value,
+    chimeVolume:         parseInt(stChimeVolume.value, 10),
+    fadeInSec:           parseInt(stFadeIn.value, 10) || 0,
+    crossfadeSec:        parseInt(stCrossfade.value, 10) || 0,
+    fadeOutSec:          parseInt(stFadeOut.value, 10) || 0,
+    normalizeLoudness:   stNormalize.checked,
+    audioDevice:         stDevice.value,
   };
   await SaveSettings(s).catch(console.error);
   settings = s;
@@ -324,32 +511,72 @@ EventsOn('timerTicked', (data) => {
   }
 });
 
-EventsOn('timerCompleted', async () => {
-  setRunningUI(false);
-  fillEl.style.width = '0%';
+EventsOn('timerSegmentStarted', (data) => {
+  sessionType = data.segmentKind === 'break' ? 'break' : 'work';
+  totalSec    = data.totalSec;
+  remainSec   = data.remainingSec;
+  updateTimerUI(remainSec, totalSec);
+  updateModeBadge();
+  setRunningUI(true);
+  if (!isMuted && settings.autoStartAudio !== false) {
+    PlaySegmentAudio().catch(console.error);
+  }
+});
 
-  if (sessionType === 'work') {
-    // Work session done — record stats
-    try { updateStatsUI(await RecordSessionComplete()); } catch (_) {}
-    if (settings.notifyOnComplete) {
-      try { new Notification('FocusPlay', { body: 'Work session complete! Take a break.' }); } catch (_) {}
-    }
-    // Auto-start break if profile has one configured
-    if (activeProfile && activeProfile.breakDurationSec > 0) {
-      setTimeout(() => startBreak(activeProfile), 800);
-      return;
-    }
-  } else {
-    // Break done — switch back to work mode
-    sessionType = 'work';
-    totalSec    = activeProfile ? activeProfile.durationSec : 25 * 60;
-    updateModeBadge();
+EventsOn('timerWarning', (data) => {
+  if (settings.notifyOnComplete) {
+    const mins = Math.round(data.thresholdSec / 60);
+    const left = data.thresholdSec >= 60 ? `${mins} min` : `${data.thresholdSec} s`;
+    try { new Notification('FocusPlay', { body: `${left} left in ${data.segmentName || 'this session'}.` }); } catch (_) {}
+  }
+});
+
+EventsOn('timerSegmentCompleted', async (data) => {
+  if (data.segmentKind === 'work') {
     if (settings.notifyOnComplete) {
-      try { new Notification('FocusPlay', { body: "Break's over! Time to focus." }); } catch (_) {}
+      try { new Notification('FocusPlay', { body: `${data.segmentName || 'Work session'} complete! Take a break.` }); } catch (_) {}
     }
+  } else if (settings.notifyOnComplete) {
+    try { new Notification('FocusPlay', { body: "Break's over! Time to focus." }); } catch (_) {}
   }
+});
 
-  remainSec = totalSec;
+EventsOn('timerUnpaused', async () => {
+  setRunningUI(true);
+  if (isMuted) return;
+  // Continue the paused song where it left off; start fresh only if nothing was paused
+  const resumed = await ResumeAudio().catch(() => false);
+  if (!resumed && settings.autoStartAudio !== false) {
+    PlaySegmentAudio().catch(console.error);
+  }
+});
+
+EventsOn('timerAbandoned', (data) => {
+  setRunningUI(false);
+  isPaused    = false;
+  sessionType = 'work';
+  totalSec    = activeProfile ? firstSegmentSec(activeProfile) : totalSec;
+  remainSec   = totalSec;
+  updateTimerUI(remainSec, totalSec);
+  updateModeBadge();
+  StopAudio().catch(console.error);
+  if (settings.notifyOnComplete) {
+    try { new Notification('FocusPlay', { body: `Session abandoned after pausing for ${fmt(data.pausedSec)}.` }); } catch (_) {}
+  }
+});
+
+EventsOn('timerCompleted', async (data) => {
+  setRunningUI(false);
+  isPaused = false;
+  // A whole session counts once in the stats, however many work blocks it has
+  if (data && data.hasWork) {
+    try { updateStatsUI(await RecordSessionComplete()); } catch (_) {}
+  }
+  fillEl.style.width = '0%';
+  sessionType = 'work';
+  totalSec    = activeProfile ? firstSegmentSec(activeProfile) : 25 * 60;
+  remainSec   = totalSec;
+  updateModeBadge();
   updateTimerUI(remainSec, totalSec);
   if (settings.autoStartNextTimer) {
     const next = activeProfile || profiles.find(p => p.id === profileSelect.value) || profiles[0];
@@ -357,14 +584,53 @@ EventsOn('timerCompleted', async () => {
   }
 });
 
+// Scheduled sessions are started by the backend; adopt the profile so badges,
+// stats and auto-next behave as if Start had been pressed.
+EventsOn('scheduleStarted', (data) => {
+  const prof = profiles.find(p => p.id === data.profileId);
+  if (prof) { activeProfile = prof; profileSelect.value = prof.id; }
+  resumeBanner.style.display = 'none';
+});
+
+// A scheduled start never replaces a session in progress
+EventsOn('scheduleSkipped', (data) => {
+  if (settings.notifyOnComplete) {
+    try { new Notification('FocusPlay', { body: `${data.profileName || 'Scheduled session'} skipped: ${data.reason}.` }); } catch (_) {}
+  }
+});
+
+EventsOn('scheduleUpcoming', (data) => {
+  if (settings.notifyOnComplete) {
+    try { new Notification('FocusPlay', { body: `${data.profileName || 'Scheduled session'} starts in ${data.minutesLeft} min.` }); } catch (_) {}
+  }
+});
+
 EventsOn('audioStateChanged', (data) => updateAudioUI(data));
 
+// Non-fatal audio problems, e.g. playlist entries that no longer exist
+EventsOn('audioWarning', (data) => {
+  console.warn(data.message, data.paths || []);
+  try { new Notification('FocusPlay', { body: data.message }); } catch (_) {}
+});
+
 function updateAudioUI(data) {
   if (!data) return;
+  renderLayerMix(data.layers, data.trackName);
+  updateTrackMeta(data);
+  muffleBtn.classList.toggle('is-on', !!data.muffled);
+  // The volume can also change from the desktop media controls
+  if (data.volume !== undefined && !volumeSlider.matches(':active')) volumeSlider.value = data.volume;
+  const meta  = data.meta || {};
+  const title = meta.title || data.trackName;
+  const byline = [meta.artist, meta.album].filter(Boolean).join(' \u2014 ');
   if (data.state === 'playing') {
     audioDot.classList.add('active');
-    trackNameEl.textContent = data.trackName || 'Playing';
-    audioSubEl.textContent  = data.trackInfo  || '';
+    trackNameEl.textContent = title || 'Playing';
+    audioSubEl.textContent  = byline || data.trackInfo || '';
+  } else if (data.state === 'paused') {
+    audioDot.classList.remove('active');
+    trackNameEl.textContent = title || 'Paused';
+    audioSubEl.textContent  = byline ? `Paused \u00b7 ${byline}` : 'Paused';
   } else {
     audioDot.classList.remove('active');
     trackNameEl.textContent = data.trackName || 'No audio';
@@ -372,6 +638,61 @@ function updateAudioUI(data) {
   }
 }
 
+// Cover art, fetched once per track, and an elapsed / duration clock that
+// runs locally between state events
+let trackClock = null;
+let coverPath = '';
+function updateTrackMeta(data) {
+  const meta = data.state !== 'stopped' ? data.meta : null;
+  favTrackBtn.dataset.path = meta && meta.path ? meta.path : '';
+  favTrackBtn.classList.toggle('is-fav', !!(meta && meta.favorite));
+  updateCover(meta && meta.path ? meta.path : '');
+  trackClock = meta && meta.durationMs
+    ? { elapsed: meta.elapsedMs, duration: meta.durationMs, at: Date.now(), running: data.state === 'playing' }
+    : null;
+  renderTrackClock();
+}
+async function updateCover(path) {
+  if (path === coverPath) return;
+  coverPath = path;
+  audioCoverEl.style.display = 'none';
+  audioCoverEl.removeAttribute('src');
+  if (!path) return;
+  const cover = await GetCoverArt(path).catch(() => '');
+  if (!cover || path !== coverPath) return; // the track changed meanwhile
+  audioCoverEl.src = cover;
+  audioCoverEl.style.display = '';
+}
+function renderTrackClock() {
+  if (!trackClock) { audioTimeEl.textContent = ''; return; }
+  const ms = trackClock.elapsed + (trackClock.running ? Date.now() - trackClock.at : 0);
+  const elapsed = Math.min(Math.floor(ms / 1000), Math.floor(trackClock.duration / 1000));
+  audioTimeEl.textContent = `${fmt(elapsed)} / ${fmt(Math.floor(trackClock.duration / 1000))}`;
+}
+setInterval(renderTrackClock, 1000);
+
+// One live volume slider per soundscape layer
+const layerMix = document.getElementById('layerMix');
+function renderLayerMix(layers, name) {
+  if (!layers || !layers.length) { layerMix.innerHTML = ''; layerMix.dataset.key = ''; return; }
+  const key = `${name}/${layers.length}`;
+  if (layerMix.dataset.key === key) return; // same soundscape: keep sliders mid-drag
+  layerMix.dataset.key = key;
+  layerMix.innerHTML = layers.map((l, i) => `
+    <label class="layer-row"><span>${escHtml(layerLabel(l.source))}</span>
+      <input type="range" min="0" max="100" value="${l.volume}" data-layer="${i}"/></label>`).join('');
+}
+layerMix.addEventListener('input', (e) => {
+  const i = e.target.dataset.layer;
+  if (i !== undefined) SetLayerVolume(parseInt(i, 10), parseInt(e.target.value, 10)).catch(() => {});
+});
+
+function layerLabel(source) {
+  if (source.startsWith('noise:')) return source.slice(6) + ' noise';
+  if (source.startsWith('tone:')) return source.slice(5).split(':').slice(0, 3).join(' ');
+  return source.split(/[\\/]/).pop();
+}
+
 function updateStatsUI(data) {
   if (!data) return;
   const countEl  = document.getElementById('sessionCount');
@@ -381,45 +702,25 @@ function updateStatsUI(data) {
 }
 
 // ── Timer controls ────────────────────────────────────────────────────────────
+// The backend runs the whole work/break sequence and emits timerSegmentStarted,
+// which drives the mode badge and segment audio.
 async function startSession(profile) {
   activeProfile = profile;
   sessionType   = 'work';
-  totalSec      = profile.durationSec;
+  totalSec      = firstSegmentSec(profile);
   remainSec     = totalSec;
   updateTimerUI(remainSec, totalSec);
   setRunningUI(true);
   updateModeBadge();
   resumeBanner.style.display = 'none';
-  await StartTimer(profile.id, profile.durationSec).catch(console.error);
-  if (!isMuted && profile.musicPath && settings.autoStartAudio !== false) {
-    if (profile.shuffle) await PlayShuffleFolder(profile.musicPath).catch(console.error);
-    else                 await PlayLooping(profile.musicPath).catch(console.error);
-  }
-}
-
-async function startBreak(profile) {
-  sessionType = 'break';
-  totalSec    = profile.breakDurationSec;
-  remainSec   = totalSec;
-  updateTimerUI(remainSec, totalSec);
-  setRunningUI(true);
-  updateModeBadge();
-  await StartTimer(profile.id + '-break', profile.breakDurationSec).catch(console.error);
-  if (!isMuted && settings.autoStartAudio !== false) {
-    if (profile.breakMusicPath === '__none__') {
-      // Explicitly no music for break
-      await StopAudio().catch(console.error);
-    } else {
-      // Use break music if set, otherwise fall back to work music
-      const musicPath = profile.breakMusicPath || profile.musicPath;
-      const shuffle   = profile.breakMusicPath ? profile.breakShuffle : profile.shuffle;
-      if (musicPath) {
-        if (shuffle) await PlayShuffleFolder(musicPath).catch(console.error);
-        else         await PlayLooping(musicPath).catch(console.error);
-      } else {
-        await StopAudio().catch(console.error);
-      }
-    }
+  try {
+    await StartProfile(profile.id);
+  } catch (err) {
+    // e.g. every segment has a zero duration: nothing will tick, so stay stopped
+    setRunningUI(false);
+    totalSec = remainSec = 0;
+    updateTimerUI(remainSec, totalSec);
+    alert(`Cannot start "${profile.name}": ${err}`);
   }
 }
 
@@ -519,20 +820,24 @@ miniPlayPause.addEventListener('click', () => {
 })();
 
 startBtn.addEventListener('click', async () => {
-  if (!isRunning) {
+  if (isPaused) {
+    await UnpauseTimer().catch(console.error);
+  } else if (!isRunning) {
     const sel = profiles.find(p => p.id === profileSelect.value) || profiles[0];
     if (sel) await startSession(sel);
   } else {
     setRunningUI(false);
+    isPaused = true;
     await PauseTimer().catch(console.error);
-    await StopAudio().catch(console.error);
+    await PauseAudio().catch(console.error);
   }
 });
 
 stopBtn.addEventListener('click', async () => {
   setRunningUI(false);
+  isPaused    = false;
   sessionType = 'work';
-  totalSec    = activeProfile ? activeProfile.durationSec : totalSec;
+  totalSec    = activeProfile ? firstSegmentSec(activeProfile) : totalSec;
   remainSec   = totalSec;
   updateTimerUI(remainSec, totalSec);
   updateModeBadge();
@@ -542,30 +847,10 @@ stopBtn.addEventListener('click', async () => {
 });
 
 skipBtn.addEventListener('click', async () => {
-  setRunningUI(false);
-  await StopTimer().catch(console.error);
-
-  if (sessionType === 'break') {
-    // Skip break → back to work ready
-    sessionType = 'work';
-    totalSec    = activeProfile ? activeProfile.durationSec : 25 * 60;
-    remainSec   = totalSec;
-    updateTimerUI(remainSec, totalSec);
-    fillEl.style.width = '0%';
-    updateModeBadge();
-    await StopAudio().catch(console.error);
-  } else {
-    // Skip work → if profile has a break, start it; else reset like stop
-    fillEl.style.width = '0%';
-    if (activeProfile && activeProfile.breakDurationSec > 0) {
-      setTimeout(() => startBreak(activeProfile), 300);
-    } else {
-      totalSec  = activeProfile ? activeProfile.durationSec : 25 * 60;
-      remainSec = totalSec;
-      updateTimerUI(remainSec, totalSec);
-      await StopAudio().catch(console.error);
-    }
-  }
+  // Skip the current segment, paused or not; skipping the last one completes the session
+  if (!isRunning && !isPaused) return;
+  fillEl.style.width = '0%';
+  await SkipSegment().catch(console.error);
 });
 
 resumeBtn.addEventListener('click', async () => {
@@ -575,27 +860,35 @@ resumeBtn.addEventListener('click', async () => {
   remainSec = savedSession.remainingSec;
   updateTimerUI(remainSec, totalSec);
   setRunningUI(true);
+  activeProfile = profiles.find(p => p.id === savedSession.profileId) || null;
+  // timerSegmentStarted restores the mode badge and restarts the segment audio
   await ResumeTimer(savedSession).catch(console.error);
-  // Restart audio for the resumed profile
-  const prof = profiles.find(p => p.id === savedSession.profileId);
-  if (!isMuted && prof && prof.musicPath && settings.autoStartAudio !== false) {
-    if (prof.shuffle) await PlayShuffleFolder(prof.musicPath).catch(console.error);
-    else              await PlayLooping(prof.musicPath).catch(console.error);
-  }
 });
 
 profileSelect.addEventListener('change', async () => {
   const sel = profiles.find(p => p.id === profileSelect.value);
   if (!sel) return;
-  if (isRunning) { await StopTimer().catch(console.error); setRunningUI(false); }
+  if (isRunning || isPaused) { await StopTimer().catch(console.error); setRunningUI(false); isPaused = false; }
   // Always stop audio when switching profiles
   await StopAudio().catch(console.error);
-  totalSec  = sel.durationSec;
+  totalSec  = firstSegmentSec(sel);
   remainSec = totalSec;
   updateTimerUI(remainSec, totalSec);
   fillEl.style.width = '0%';
 });
 
+prevTrackBtn.addEventListener('click', () => PreviousTrack().catch(() => {}));
+nextTrackBtn.addEventListener('click', () => NextTrack().catch(() => {}));
+banTrackBtn.addEventListener('click', () => BanTrack().catch(() => {}));
+muffleBtn.addEventListener('click', () => SetMuffle(!muffleBtn.classList.contains('is-on')).catch(console.error));
+favTrackBtn.addEventListener('click', async () => {
+  const path = favTrackBtn.dataset.path;
+  if (!path) return;
+  const prefs = await GetTrackPrefs(path);
+  prefs.favorite = !prefs.favorite;
+  await SetTrackPrefs(path, prefs).catch(console.error);
+});
+
 volumeSlider.addEventListener('input', async () => {
   await SetVolume(parseInt(volumeSlider.value, 10)).catch(console.error);
 });
@@ -642,6 +935,18 @@ document.addEventListener('keydown', (e) => {
       e.preventDefault();
       document.getElementById('openMini').click();
       break;
+    case 'KeyN':
+      e.preventDefault();
+      nextTrackBtn.click();
+      break;
+    case 'KeyU':
+      e.preventDefault();
+      muffleBtn.click();
+      break;
+    case 'KeyP':
+      e.preventDefault();
+      prevTrackBtn.click();
+      break;
   }
 });
 
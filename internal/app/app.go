@@ -2,14 +2,22 @@ package app
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"focusplay/internal/domain"
 	"focusplay/internal/infra/events"
 	"focusplay/internal/infra/storage"
 	"focusplay/internal/services/audio"
+	"focusplay/internal/services/mpris"
 	"focusplay/internal/services/persistence"
 	"focusplay/internal/services/profile"
+	"focusplay/internal/services/scheduler"
 	"focusplay/internal/services/settings"
+	"focusplay/internal/services/soundscape"
 	"focusplay/internal/services/stats"
 	"focusplay/internal/services/timer"
 
@@ -26,29 +34,149 @@ type App struct {
 	audio       *audio.Service
 	settings    *settings.Service
 	stats       *stats.Service
+	scheduler   *scheduler.Service
+	soundscapes *soundscape.Service
+	mpris       *mpris.Service
+
+	mu      sync.Mutex
+	resumed *domain.PlaybackState // music position of a resumed session, used once by PlaySegmentAudio
 }
 
 // New creates and wires up all services.
 func New() *App {
 	dir := storage.DataDir()
 	ps := persistence.New(dir)
-	return &App{
-		profiles:    profile.New(dir),
+	profiles := profile.New(dir)
+	tm := timer.New(ps)
+	au := audio.New(dir)
+	if sink, err := audio.NewSink(os.Getenv("FOCUSPLAY_AUDIO_OUTPUT")); err == nil {
+		au.SetSink(sink)
+	} else {
+		println("Audio output:", err.Error())
+	}
+	ps.SetPlaybackSource(au.Playback)
+	a := &App{
+		profiles:    profiles,
 		persistence: ps,
-		timer:       timer.New(ps),
-		audio:       audio.New(),
+		timer:       tm,
+		audio:       au,
 		settings:    settings.New(dir),
 		stats:       stats.New(dir),
+		scheduler:   scheduler.New(dir, tm, profiles.GetByID),
+		soundscapes: soundscape.New(dir),
 	}
+	a.mpris = mpris.New(mediaPlayer{au, a}, a.PlaySegmentAudio)
+	return a
 }
 
 // Startup is called by Wails after the window is ready.
 func (a *App) Startup(ctx context.Context) {
 	a.ctx = ctx
-	e := events.NewWailsEmitter(ctx)
+	e := events.NewBus(events.NewWailsEmitter(ctx))
+	e.On("timerWarning", a.onTimerWarning)
+	e.On("timerSegmentCompleted", a.onSegmentCompleted)
+	e.On("timerTicked", a.onTimerTicked)
+	e.On("timerSegmentStarted", a.onSegmentStarted)
+	e.On("audioStateChanged", a.onAudioStateChanged)
 	a.timer.SetEmitter(e)
 	a.audio.SetEmitter(e)
+	a.scheduler.SetEmitter(e)
+	a.applyAudioSettings(a.settings.Get())
 	a.profiles.Load()
+	a.soundscapes.Load()
+	a.scheduler.Start()
+	if err := a.mpris.Start(); err != nil {
+		println("Media controls:", err.Error())
+	}
+	a.mpris.Update(a.audio.GetState())
+}
+
+// Shutdown is called by Wails as the app quits. It stops the scheduler first,
+// so no scheduled start lands mid-shutdown, then saves the session with the
+// current music position, so a resume continues from here rather than the last
+// autosave, leaves the media controls and closes the audio output.
+func (a *App) Shutdown(_ context.Context) {
+	a.scheduler.Stop()
+	a.timer.Checkpoint()
+	a.mpris.Close()
+	a.audio.Close()
+}
+
+// ── Backend event handlers ──────────────────────────────────────────────────
+
+// onTimerWarning plays the countdown cue over the music when the profile asks for it.
+func (a *App) onTimerWarning(data any) {
+	payload, _ := data.(map[string]interface{})
+	if cue, _ := payload["cue"].(bool); cue {
+		go a.audio.PlayCue(audio.CueWarning)
+	}
+}
+
+// onSegmentCompleted rings the completion chime when a work block or break ends.
+func (a *App) onSegmentCompleted(_ any) {
+	s := a.settings.Get()
+	if s.PlaySoundOnComplete {
+		go a.audio.PlayChime(s.CompletionChime, s.ChimeVolume)
+	}
+}
+
+// onSegmentStarted sets the music to the volume and tone of the new work or
+// break phase.
+func (a *App) onSegmentStarted(_ any) {
+	_, seg, _ := a.timer.CurrentSegment()
+	a.audio.SetPhaseVolume(seg.Volume)
+	a.audio.SetEQ(seg.EQ)
+}
+
+// mediaPlayer is the audio as the desktop media controls drive it. A volume
+// set there is also kept as the default for the next launch, as the app has
+// no other way to hear of it.
+type mediaPlayer struct {
+	*audio.Service
+	app *App
+}
+
+func (m mediaPlayer) SetVolume(v int) {
+	v = max(0, min(v, 100))
+	m.Service.SetVolume(v)
+	if err := m.app.settings.SetDefaultVolume(v); err != nil {
+		println("Settings:", err.Error())
+	}
+}
+
+// onAudioStateChanged mirrors the audio state to the desktop media controls.
+func (a *App) onAudioStateChanged(data any) {
+	if st, ok := data.(domain.AudioStatePayload); ok {
+		a.mpris.Update(st)
+	}
+}
+
+// onTimerTicked fades the music out ahead of the end of the final segment, so
+// the session closes on silence rather than a cut.
+func (a *App) onTimerTicked(data any) {
+	payload, _ := data.(map[string]interface{})
+	remaining, _ := payload["remainingSec"].(int)
+	fadeOut := a.settings.Get().FadeOutSec
+	if fadeOut <= 0 || remaining != fadeOut {
+		return
+	}
+	state := a.timer.GetState()
+	idx, _ := state["segmentIndex"].(int)
+	count, _ := state["segmentCount"].(int)
+	if idx == count-1 {
+		go a.audio.FadeOut(time.Duration(fadeOut) * time.Second)
+	}
+}
+
+// applyAudioSettings hands the master volume, fade, loudness and output
+// device settings to the audio service.
+func (a *App) applyAudioSettings(s domain.Settings) {
+	a.audio.SetVolume(s.DefaultVolume)
+	a.audio.SetFades(time.Duration(s.FadeInSec)*time.Second, time.Duration(s.CrossfadeSec)*time.Second)
+	a.audio.SetNormalize(s.NormalizeLoudness)
+	if err := a.audio.SetDevice(s.AudioDevice); err != nil {
+		println("Audio device:", err.Error())
+	}
 }
 
 // ── Profile methods (bound to JS) ───────────────────────────────────────────
@@ -73,8 +201,8 @@ func (a *App) DeleteProfile(id string) error {
 
 func (a *App) PickMusicFile() string {
 	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
-		Title:   "Select MP3 file",
-		Filters: []runtime.FileFilter{{DisplayName: "MP3 Audio (*.mp3)", Pattern: "*.mp3"}},
+		Title:   "Select audio file or playlist",
+		Filters: audioFilters(),
 	})
 	if err != nil {
 		return ""
@@ -82,6 +210,30 @@ func (a *App) PickMusicFile() string {
 	return path
 }
 
+// audioFilters offers every format the audio service can decode, plus
+// playlists, together and one by one.
+func audioFilters() []runtime.FileFilter {
+	var all []string
+	for _, ext := range audio.SupportedExtensions() {
+		all = append(all, "*"+ext)
+	}
+	var lists []string
+	for _, ext := range audio.PlaylistExtensions() {
+		lists = append(lists, "*"+ext)
+	}
+	filters := []runtime.FileFilter{
+		{DisplayName: "Audio files and playlists", Pattern: strings.Join(append(all, lists...), ";")},
+		{DisplayName: "Playlists (" + strings.Join(lists, ", ") + ")", Pattern: strings.Join(lists, ";")},
+	}
+	for _, pattern := range all {
+		filters = append(filters, runtime.FileFilter{
+			DisplayName: strings.ToUpper(strings.TrimPrefix(pattern, "*.")) + " (" + pattern + ")",
+			Pattern:     pattern,
+		})
+	}
+	return filters
+}
+
 func (a *App) PickMusicFolder() string {
 	path, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
 		Title: "Select music folder",
@@ -104,7 +256,26 @@ func (a *App) StartTimer(profileID string, durationSec int) {
 	a.timer.Start(profileID, durationSec)
 }
 
+// StartProfile runs the profile's full segment sequence (work/break or custom
+// intervals). It fails for an unknown profile or one with nothing to time.
+func (a *App) StartProfile(profileID string) error {
+	p := a.profiles.GetByID(profileID)
+	if p == nil {
+		return fmt.Errorf("no profile %q", profileID)
+	}
+	return a.timer.StartProfile(*p)
+}
+
+func (a *App) SkipSegment() {
+	a.timer.SkipSegment()
+}
+
+// ResumeTimer continues a saved session. Its music picks up where it was when
+// the session was saved (see PlaySegmentAudio).
 func (a *App) ResumeTimer(state domain.SessionState) {
+	a.mu.Lock()
+	a.resumed = state.Playback
+	a.mu.Unlock()
 	a.timer.Resume(state)
 }
 
@@ -112,6 +283,10 @@ func (a *App) PauseTimer() {
 	a.timer.Pause()
 }
 
+func (a *App) UnpauseTimer() {
+	a.timer.Unpause()
+}
+
 func (a *App) StopTimer() {
 	a.timer.Stop()
 }
@@ -130,18 +305,191 @@ func (a *App) PlayShuffleFolder(folder string) {
 	a.audio.PlayShuffleFolder(folder)
 }
 
+// PlaySegmentAudio starts the music configured for the timer's current segment,
+// or stops audio when that segment is silent.
+func (a *App) PlaySegmentAudio() {
+	profileID, seg, ok := a.timer.CurrentSegment()
+	a.audio.SetPhaseVolume(seg.Volume)
+	a.audio.SetEQ(seg.EQ)
+	if !ok || seg.MusicPath == "" {
+		a.audio.Stop()
+		return
+	}
+	if id, ok := strings.CutPrefix(seg.MusicPath, domain.SoundscapePrefix); ok {
+		a.PlaySoundscape(id)
+		return
+	}
+	var scan domain.ScanOptions
+	if p := a.profiles.GetByID(profileID); p != nil {
+		scan = p.Scan
+	}
+	a.mu.Lock()
+	resumed := a.resumed
+	a.resumed = nil
+	a.mu.Unlock()
+	a.audio.ResumeSource(seg.MusicPath, seg.Shuffle, scan, resumed)
+}
+
+// PlayNoise plays generated noise ("white", "pink", "brown", "rain", "ocean").
+func (a *App) PlayNoise(kind string) {
+	a.audio.PlayNoise(kind)
+}
+
+func (a *App) ListNoiseKinds() []string {
+	return audio.NoiseKinds()
+}
+
+// PlayTone plays a binaural beat or isochronic tone, e.g. "tone:binaural:200:10:sine".
+func (a *App) PlayTone(source string) error {
+	spec, err := audio.ParseTone(source)
+	if err != nil {
+		return err
+	}
+	a.audio.PlayTone(spec)
+	return nil
+}
+
+func (a *App) ListTonePresets() []audio.TonePreset {
+	return audio.TonePresets()
+}
+
 func (a *App) StopAudio() {
 	a.audio.Stop()
 }
 
+// PauseAudio holds the music in place, e.g. while the timer is paused.
+func (a *App) PauseAudio() {
+	a.audio.PauseAudio()
+}
+
+// ResumeAudio continues paused music and reports false when nothing was paused.
+func (a *App) ResumeAudio() bool {
+	return a.audio.ResumeAudio()
+}
+
+func (a *App) NextTrack() error {
+	return a.audio.NextTrack()
+}
+
+func (a *App) PreviousTrack() error {
+	return a.audio.PreviousTrack()
+}
+
+// GetTrackPrefs returns the shuffle weight, favourite and ban flags of a track file.
+func (a *App) GetTrackPrefs(path string) domain.TrackPrefs {
+	return a.audio.TrackPrefs(path)
+}
+
+// GetCoverArt returns the cover art of the playing track as a data: URL, ""
+// when it has none or path is no longer playing.
+func (a *App) GetCoverArt(path string) string {
+	return a.audio.CoverArt(path)
+}
+
+func (a *App) SetTrackPrefs(path string, p domain.TrackPrefs) error {
+	return a.audio.SetTrackPrefs(path, p)
+}
+
+// BanTrack leaves the playing track out of shuffle from now on and skips it.
+func (a *App) BanTrack() error {
+	return a.audio.BanTrack()
+}
+
+// GetBadFiles lists the music files that could not be decoded and are being skipped.
+func (a *App) GetBadFiles() []domain.TrackError {
+	return a.audio.BadFiles()
+}
+
+func (a *App) ClearBadFiles() {
+	a.audio.ClearBadFiles()
+}
+
+// ListAudioDevices returns the output devices for the settings, the system
+// default first. It fails where the device cannot be chosen, so the settings
+// can say why.
+func (a *App) ListAudioDevices() ([]domain.AudioDevice, error) {
+	return a.audio.AudioDevices()
+}
+
 func (a *App) SetVolume(v int) {
 	a.audio.SetVolume(v)
 }
 
+// SetChannelVolume sets the "music", "ambient" or "cue" channel volume (0–100).
+func (a *App) SetChannelVolume(channel string, v int) error {
+	return a.audio.SetChannelVolume(channel, v)
+}
+
+// SetMuffle softens the music and ambient sound behind a steep low-pass,
+// e.g. for a quick call, until turned off again.
+func (a *App) SetMuffle(on bool) {
+	a.audio.SetMuffle(on)
+}
+
+func (a *App) PlayAmbient(filePath string) {
+	a.audio.PlayAmbient(filePath)
+}
+
+func (a *App) StopAmbient() {
+	a.audio.StopAmbient()
+}
+
 func (a *App) GetAudioState() domain.AudioStatePayload {
 	return a.audio.GetState()
 }
 
+func (a *App) ListChimes() []string {
+	return audio.Chimes()
+}
+
+// PreviewChime plays a chime once so it can be auditioned from the settings panel.
+func (a *App) PreviewChime(name string, volume int) error {
+	return a.audio.PlayChime(name, volume)
+}
+
+// ── Soundscape methods (bound to JS) ────────────────────────────────────────
+
+func (a *App) LoadSoundscapes() []domain.Soundscape {
+	return a.soundscapes.Load()
+}
+
+func (a *App) SaveSoundscape(sc domain.Soundscape) error {
+	return a.soundscapes.Save(sc)
+}
+
+func (a *App) DeleteSoundscape(id string) error {
+	return a.soundscapes.Delete(id)
+}
+
+// PlaySoundscape plays all layers of a saved soundscape in place of the music.
+func (a *App) PlaySoundscape(id string) {
+	sc := a.soundscapes.GetByID(id)
+	if sc == nil {
+		a.audio.Stop()
+		return
+	}
+	a.audio.PlaySoundscape(*sc)
+}
+
+// SetLayerVolume adjusts one layer of the playing soundscape (0–100).
+func (a *App) SetLayerVolume(index, v int) error {
+	return a.audio.SetLayerVolume(index, v)
+}
+
+// ── Schedule methods (bound to JS) ──────────────────────────────────────────
+
+func (a *App) ListSchedules() []domain.Schedule {
+	return a.scheduler.List()
+}
+
+func (a *App) SaveSchedule(sch domain.Schedule) (domain.Schedule, error) {
+	return a.scheduler.Save(sch)
+}
+
+func (a *App) DeleteSchedule(id string) error {
+	return a.scheduler.Delete(id)
+}
+
 // ── Stats methods (bound to JS) ─────────────────────────────────────────────
 
 func (a *App) GetStats() domain.StatsData {
@@ -159,5 +507,9 @@ func (a *App) GetSettings() domain.Settings {
 }
 
 func (a *App) SaveSettings(s domain.Settings) error {
-	return a.settings.Save(s)
+	if err := a.settings.Save(s); err != nil {
+		return err
+	}
+	a.applyAudioSettings(s)
+	return nil
 }
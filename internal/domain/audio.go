@@ -1,11 +1,12 @@
 package domain
 
-// AudioPlaybackState represents whether audio is idle, playing, or stopped.
+// AudioPlaybackState represents whether audio is idle, playing, paused or stopped.
 type AudioPlaybackState string
 
 const (
 	AudioIdle    AudioPlaybackState = "idle"
 	AudioPlaying AudioPlaybackState = "playing"
+	AudioPaused  AudioPlaybackState = "paused" // music held in place; ResumeAudio continues it
 	AudioStopped AudioPlaybackState = "stopped"
 )
 
@@ -13,5 +14,64 @@ const (
 type AudioStatePayload struct {
 	State     AudioPlaybackState `json:"state"`
 	TrackName string             `json:"trackName"`
-	TrackInfo string             `json:"trackInfo"` // e.g. "Shuffle folder · 12 tracks"
+	TrackInfo string             `json:"trackInfo"`         // e.g. "Shuffle folder · 12 tracks"
+	Layers    []SoundLayer       `json:"layers,omitempty"`  // set while a soundscape plays
+	Meta      *TrackMeta         `json:"meta,omitempty"`    // set while a file, folder or playlist plays
+	Errors    []TrackError       `json:"errors,omitempty"`  // tracks of the playing list that failed, latest last
+	Muffled   bool               `json:"muffled,omitempty"` // music and ambient low-passed by SetMuffle
+	Volume    int                `json:"volume"`            // master music volume, 0–100
+}
+
+// EQ shapes the tone of the music in a work or break phase: optional low-
+// and high-pass filters and a three-band equaliser. The zero value leaves
+// the music as it is.
+type EQ struct {
+	LowPassHz  int     `json:"lowPassHz,omitempty"`  // cut the treble above this frequency (0 = off)
+	HighPassHz int     `json:"highPassHz,omitempty"` // cut the bass below this frequency (0 = off)
+	BassDB     float64 `json:"bassDb,omitempty"`     // shelf below 250 Hz, -12 to +12
+	MidDB      float64 `json:"midDb,omitempty"`      // bell around 1 kHz, -12 to +12
+	TrebleDB   float64 `json:"trebleDb,omitempty"`   // shelf above 4 kHz, -12 to +12
+}
+
+// AudioDevice is an output device that the audio can be sent to.
+type AudioDevice struct {
+	ID      string `json:"id"` // "" for the system default
+	Name    string `json:"name"`
+	Default bool   `json:"default,omitempty"` // the device the system default currently plays to
+}
+
+// TrackError is a track file that could not be played and why.
+type TrackError struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// TrackMeta describes the playing track from its tags (ID3, Vorbis comments)
+// and its decoded length. Tag fields are empty when the file has none.
+type TrackMeta struct {
+	Path       string `json:"path,omitempty"` // file being played, for SetTrackPrefs
+	Title      string `json:"title"`
+	Artist     string `json:"artist"`
+	Album      string `json:"album"`
+	DurationMs int64  `json:"durationMs"` // 0 when the length is unknown
+	ElapsedMs  int64  `json:"elapsedMs"`  // position when the state was taken
+	Favorite   bool   `json:"favorite,omitempty"`
+}
+
+// TrackPrefs steers how often shuffle picks a track. The zero value is a
+// normal track.
+type TrackPrefs struct {
+	Weight   float64 `json:"weight,omitempty"` // relative chance of playing early in a pass; 0 means 1
+	Favorite bool    `json:"favorite,omitempty"`
+	Banned   bool    `json:"banned,omitempty"` // never played while anything else is left
+}
+
+// PlaybackState records where a file, folder or playlist had got to, so a
+// resumed session continues the same song in the same shuffle order.
+type PlaybackState struct {
+	Source     string   `json:"source"` // the segment's music path
+	Shuffle    bool     `json:"shuffle"`
+	Tracks     []string `json:"tracks"` // play order
+	Index      int      `json:"index"`
+	PositionMs int64    `json:"positionMs"` // offset into Tracks[Index]
 }
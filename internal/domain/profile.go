@@ -5,10 +5,72 @@ type Profile struct {
 	ID               string `json:"id"`
 	Name             string `json:"name"`
 	DurationSec      int    `json:"durationSec"`      // work session length in seconds
-	MusicPath        string `json:"musicPath"`        // work music: file or folder
+	MusicPath        string `json:"musicPath"`        // work music: file, folder, playlist or stream URL
 	Shuffle          bool   `json:"shuffle"`          // true = shuffle work music folder
 	BreakDurationSec int    `json:"breakDurationSec"` // break length (0 = no break)
-	BreakMusicPath   string `json:"breakMusicPath"`   // break music: file or folder (empty = silent)
+	BreakMusicPath   string `json:"breakMusicPath"`   // break music: file, folder, playlist or stream URL (empty = silent)
 	BreakShuffle     bool   `json:"breakShuffle"`     // true = shuffle break music folder
+	WorkVolume       int    `json:"workVolume"`       // work music level, % of the master volume (0 = 100)
+	BreakVolume      int    `json:"breakVolume"`      // break music level, % of the master volume (0 = 100)
+	WorkEQ           EQ     `json:"workEq"`           // tone of the work music
+	BreakEQ          EQ     `json:"breakEq"`          // tone of the break music
 	IsDefault        bool   `json:"isDefault"`        // selected automatically on startup
+	MaxPauseSec      int    `json:"maxPauseSec"`      // longest single pause allowed (0 = unlimited)
+	PauseAction      string `json:"pauseAction"`      // when MaxPauseSec is exceeded: "abandon" | "resume"
+	WarningSec       []int  `json:"warningSec"`       // emit "timerWarning" at these seconds left in each segment
+	WarningCue       bool   `json:"warningCue"`       // also play a short audio cue at each warning
+
+	// Scan controls how music folders of this profile are searched for tracks.
+	Scan ScanOptions `json:"scan"`
+
+	// Segments overrides the single work/break pair above when non-empty.
+	Segments []Segment `json:"segments,omitempty"`
+}
+
+// ScanOptions controls how a music folder is searched for tracks. Patterns
+// without a "/" match file or folder names; patterns with one match the path
+// relative to the music folder, where "**" spans any number of folders.
+type ScanOptions struct {
+	MaxDepth int      `json:"maxDepth,omitempty"` // subfolder levels to descend (0 = default, -1 = top level only)
+	Include  []string `json:"include,omitempty"`  // when set, only files matching one of these play
+	Exclude  []string `json:"exclude,omitempty"`  // files and folders to skip
+}
+
+// PauseAction values for Profile.PauseAction.
+const (
+	PauseAbandon = "abandon"
+	PauseResume  = "resume"
+)
+
+// NoMusic is the sentinel BreakMusicPath meaning "play nothing during the break".
+const NoMusic = "__none__"
+
+// Plan returns the expanded segment sequence for this profile. Profiles without
+// explicit Segments become one work block followed by an optional break, with
+// break music falling back to the work music unless set to NoMusic.
+func (p Profile) Plan() []Segment {
+	if len(p.Segments) > 0 {
+		return ExpandSegments(p.Segments)
+	}
+	plan := []Segment{{
+		Name:        "Work",
+		DurationSec: p.DurationSec,
+		Kind:        SegmentWork,
+		MusicPath:   p.MusicPath,
+		Shuffle:     p.Shuffle,
+		Volume:      p.WorkVolume,
+		EQ:          p.WorkEQ,
+	}}
+	if p.BreakDurationSec > 0 {
+		brk := Segment{Name: "Break", DurationSec: p.BreakDurationSec, Kind: SegmentBreak, Volume: p.BreakVolume, EQ: p.BreakEQ}
+		switch p.BreakMusicPath {
+		case NoMusic:
+		case "":
+			brk.MusicPath, brk.Shuffle = p.MusicPath, p.Shuffle
+		default:
+			brk.MusicPath, brk.Shuffle = p.BreakMusicPath, p.BreakShuffle
+		}
+		plan = append(plan, brk)
+	}
+	return ExpandSegments(plan)
 }
@@ -0,0 +1,14 @@
+package domain
+
+// Schedule starts a profile automatically at a fixed local clock time,
+// persisted to schedules.json.
+type Schedule struct {
+	ID          string `json:"id"`
+	ProfileID   string `json:"profileId"`
+	Time        string `json:"time"`        // "HH:MM", 24 h local time
+	Weekdays    []int  `json:"weekdays"`    // 0 = Sunday … 6 = Saturday (empty = every day)
+	WarnMinutes int    `json:"warnMinutes"` // emit "scheduleUpcoming" this long before (0 = no warning)
+	Enabled     bool   `json:"enabled"`
+	LastRun     int64  `json:"lastRun"`    // Unix time of the last occurrence started
+	LastWarned  int64  `json:"lastWarned"` // Unix time of the occurrence last warned about
+}
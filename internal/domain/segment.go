@@ -0,0 +1,54 @@
+package domain
+
+// SegmentKind distinguishes focus blocks from breaks inside a sequence.
+type SegmentKind string
+
+const (
+	SegmentWork  SegmentKind = "work"
+	SegmentBreak SegmentKind = "break"
+)
+
+// Segment is one timed block of a profile's interval sequence.
+type Segment struct {
+	Name        string      `json:"name"`
+	DurationSec int         `json:"durationSec"`
+	Kind        SegmentKind `json:"kind"`       // "work" | "break"
+	MusicPath   string      `json:"musicPath"`  // file or folder (empty = silent)
+	Shuffle     bool        `json:"shuffle"`    // true = shuffle MusicPath folder
+	Volume      int         `json:"volume"`     // music level, % of the master volume (0 = 100)
+	EQ          EQ          `json:"eq"`         // tone of the music
+	Repeat      int         `json:"repeat"`     // times to run (0 or 1 = once)
+	RepeatSpan  int         `json:"repeatSpan"` // segments repeated together, starting here (0 or 1 = just this one)
+}
+
+// ExpandSegments flattens repeats into the linear order the timer executes.
+// e.g. [warm-up, work×3 span 2, break, review] → warm-up, work, break, work, break, work, break, review.
+// Segments with a zero or negative duration are dropped.
+func ExpandSegments(segs []Segment) []Segment {
+	var out []Segment
+	for i := 0; i < len(segs); {
+		span := segs[i].RepeatSpan
+		if span < 1 {
+			span = 1
+		}
+		if i+span > len(segs) {
+			span = len(segs) - i
+		}
+		times := segs[i].Repeat
+		if times < 1 {
+			times = 1
+		}
+		for n := 0; n < times; n++ {
+			for _, seg := range segs[i : i+span] {
+				if seg.DurationSec <= 0 {
+					continue
+				}
+				seg.Repeat = 0
+				seg.RepeatSpan = 0
+				out = append(out, seg)
+			}
+		}
+		i += span
+	}
+	return out
+}
@@ -2,11 +2,21 @@ package domain
 
 // SessionState is persisted to state.json so a session survives restarts.
 // SavedAt is a Unix timestamp (int64) to avoid Wails binding issues with time.Time.
+// TotalSec and RemainingSec refer to the current segment of the sequence.
 type SessionState struct {
-	ProfileID    string `json:"profileId"`
-	TotalSec     int    `json:"totalSec"`
-	RemainingSec int    `json:"remainingSec"`
-	SavedAt      int64  `json:"savedAt"`
+	ProfileID    string         `json:"profileId"`
+	TotalSec     int            `json:"totalSec"`
+	RemainingSec int            `json:"remainingSec"`
+	SavedAt      int64          `json:"savedAt"`
+	Segments     []Segment      `json:"segments,omitempty"` // expanded plan (empty = single block of TotalSec)
+	SegmentIndex int            `json:"segmentIndex"`
+	PauseCount   int            `json:"pauseCount"`
+	PausedSec    int            `json:"pausedSec"`   // total time spent paused this session
+	MaxPauseSec  int            `json:"maxPauseSec"` // copied from the profile so the limit survives restarts
+	PauseAction  string         `json:"pauseAction"`
+	WarningSec   []int          `json:"warningSec,omitempty"`
+	WarningCue   bool           `json:"warningCue"`
+	Playback     *PlaybackState `json:"playback,omitempty"` // music position, when a track list was playing
 }
 
 // StatsData holds daily session counts and a running streak, persisted to stats.json.
@@ -2,20 +2,34 @@ package domain
 
 // Settings holds global app preferences persisted to settings.json.
 type Settings struct {
-	DefaultVolume      int    `json:"defaultVolume"` // 0-100
-	AutoStartAudio     bool   `json:"autoStartAudio"`
-	NotifyOnComplete   bool   `json:"notifyOnComplete"`
-	AutoStartNextTimer bool   `json:"autoStartNextTimer"`
-	Theme              string `json:"theme"` // "dark" | "ocean" | "forest" | "minimal-black"
+	DefaultVolume       int    `json:"defaultVolume"` // 0-100
+	AutoStartAudio      bool   `json:"autoStartAudio"`
+	NotifyOnComplete    bool   `json:"notifyOnComplete"`
+	AutoStartNextTimer  bool   `json:"autoStartNextTimer"`
+	Theme               string `json:"theme"` // "dark" | "ocean" | "forest" | "minimal-black"
+	PlaySoundOnComplete bool   `json:"playSoundOnComplete"`
+	CompletionChime     string `json:"completionChime"`   // embedded chime name, e.g. "bell"
+	ChimeVolume         int    `json:"chimeVolume"`       // 0-100, independent of the music volume
+	FadeInSec           int    `json:"fadeInSec"`         // music fade-in when a session starts
+	CrossfadeSec        int    `json:"crossfadeSec"`      // overlap between shuffled tracks and work/break music
+	FadeOutSec          int    `json:"fadeOutSec"`        // music fade-out before the session completes
+	NormalizeLoudness   bool   `json:"normalizeLoudness"` // bring tracks to a common loudness (ReplayGain or measured)
+	AudioDevice         string `json:"audioDevice"`       // output device ID; "" or a disconnected device means the system default
 }
 
 // DefaultSettings returns the factory defaults shown on first run.
 func DefaultSettings() Settings {
 	return Settings{
-		DefaultVolume:      70,
-		AutoStartAudio:     true,
-		NotifyOnComplete:   true,
-		AutoStartNextTimer: false,
-		Theme:              "dark",
+		DefaultVolume:       70,
+		AutoStartAudio:      true,
+		NotifyOnComplete:    true,
+		AutoStartNextTimer:  false,
+		Theme:               "dark",
+		PlaySoundOnComplete: true,
+		CompletionChime:     "bell",
+		ChimeVolume:         80,
+		FadeInSec:           3,
+		CrossfadeSec:        4,
+		FadeOutSec:          5,
 	}
 }
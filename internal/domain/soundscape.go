@@ -0,0 +1,21 @@
+package domain
+
+// SoundscapePrefix marks a soundscape reference in a profile music path,
+// e.g. "soundscape:rainy-cafe".
+const SoundscapePrefix = "soundscape:"
+
+// SoundLayer is one source in a soundscape.
+type SoundLayer struct {
+	Source  string `json:"source"`  // file, folder, stream URL, noise ("noise:rain") or tone ("tone:binaural:200:10")
+	Volume  int    `json:"volume"`  // 0–100, relative to the music volume
+	Loop    bool   `json:"loop"`    // restart the file / folder when it ends
+	Shuffle bool   `json:"shuffle"` // folders only: play tracks in random order
+}
+
+// Soundscape is a named set of layers that play together, e.g. rain + café
+// chatter + music.
+type Soundscape struct {
+	ID     string       `json:"id"`
+	Name   string       `json:"name"`
+	Layers []SoundLayer `json:"layers"`
+}
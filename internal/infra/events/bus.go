@@ -0,0 +1,36 @@
+package events
+
+import "sync"
+
+// Bus lets Go services react to each other's events. Emit runs the in-process
+// handlers registered for the event, then forwards it to the next Emitter
+// (usually the Wails emitter, so the frontend still sees everything).
+type Bus struct {
+	mu       sync.RWMutex
+	next     Emitter
+	handlers map[string][]func(data any)
+}
+
+// NewBus creates a Bus that forwards every event to next.
+func NewBus(next Emitter) *Bus {
+	return &Bus{next: next, handlers: map[string][]func(data any){}}
+}
+
+// On registers fn to run synchronously whenever event is emitted.
+// Handlers must not block; hand long work off to a goroutine.
+func (b *Bus) On(event string, fn func(data any)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[event] = append(b.handlers[event], fn)
+}
+
+func (b *Bus) Emit(event string, data any) {
+	b.mu.RLock()
+	handlers := b.handlers[event]
+	next := b.next
+	b.mu.RUnlock()
+	for _, fn := range handlers {
+		fn(data)
+	}
+	next.Emit(event, data)
+}
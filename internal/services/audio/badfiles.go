@@ -0,0 +1,107 @@
+package audio
+
+import (
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"focusplay/internal/domain"
+	"focusplay/internal/infra/storage"
+)
+
+const (
+	trackRetry     = 50 * time.Millisecond // first pause after a track fails; see retryDelay
+	maxTrackRetry  = 2 * time.Second
+	maxTrackErrors = 20 // failed tracks kept in the audio state
+)
+
+// badFiles remembers files that exist but could not be decoded, in
+// bad-files.json, so folders and playlists leave them out instead of failing
+// on them every pass. A file that changes on disk is tried again.
+type badFiles struct {
+	mu      sync.Mutex
+	path    string // "" keeps the list in memory only
+	entries map[string]badEntry
+}
+
+type badEntry struct {
+	Error   string `json:"error"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"modTime"` // Unix ns
+}
+
+func newBadFiles(filePath string) *badFiles {
+	b := &badFiles{path: filePath}
+	if filePath != "" {
+		_ = storage.Load(filePath, &b.entries)
+	}
+	if b.entries == nil {
+		b.entries = map[string]badEntry{}
+	}
+	return b
+}
+
+// add records that path failed with err. Missing files are not recorded:
+// they may be on a drive that is not mounted yet.
+func (b *badFiles) add(path string, err error) {
+	info, statErr := os.Stat(path)
+	if statErr != nil || info.IsDir() {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[path] = badEntry{Error: err.Error(), Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+	b.saveLocked()
+}
+
+// playable drops the files known to be bad, unless that would leave nothing,
+// in which case they are all tried again.
+func (b *badFiles) playable(tracks []string) []string {
+	var out []string
+	for _, t := range tracks {
+		if !b.has(t) {
+			out = append(out, t)
+		}
+	}
+	if len(out) == 0 {
+		return tracks
+	}
+	return out
+}
+
+// has reports whether path is recorded as bad and has not changed since.
+func (b *badFiles) has(path string) bool {
+	b.mu.Lock()
+	e, ok := b.entries[path]
+	b.mu.Unlock()
+	if !ok {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Size() == e.Size && info.ModTime().UnixNano() == e.ModTime
+}
+
+func (b *badFiles) list() []domain.TrackError {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]domain.TrackError, 0, len(b.entries))
+	for p, e := range b.entries {
+		out = append(out, domain.TrackError{Path: p, Error: e.Error})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+func (b *badFiles) clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = map[string]badEntry{}
+	b.saveLocked()
+}
+
+func (b *badFiles) saveLocked() {
+	if b.path != "" {
+		_ = storage.Save(b.path, b.entries)
+	}
+}
@@ -0,0 +1,108 @@
+package audio
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"focusplay/internal/domain"
+)
+
+func writeCorrupt(t *testing.T, path string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte("RIFF\x10\x00\x00\x00WAVEjunk\x08\x00\x00\x00ab"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitState waits for the audio state to satisfy ok.
+func waitState(t *testing.T, svc *Service, ok func(domain.AudioStatePayload) bool) domain.AudioStatePayload {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		st := svc.GetState()
+		if ok(st) {
+			return st
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("state never matched, last %+v", st)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBadTrackIsReportedAndLeftOut(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "a.wav")
+	writeCorrupt(t, bad)
+	writeWAV(t, filepath.Join(dir, "b.wav"))
+	svc := newTestOutput()
+	svc.PlayFolder(dir, false, domain.ScanOptions{})
+
+	st := waitState(t, svc, func(st domain.AudioStatePayload) bool { return st.TrackName == "b.wav" })
+	if len(st.Errors) != 1 || st.Errors[0].Path != bad || st.Errors[0].Error == "" {
+		t.Errorf("want a.wav reported in the state, got %+v", st.Errors)
+	}
+	if list := svc.BadFiles(); len(list) != 1 || list[0].Path != bad {
+		t.Errorf("want a.wav in the bad files, got %+v", list)
+	}
+
+	svc.PlayFolder(dir, false, domain.ScanOptions{})
+	if pb := svc.Playback(); len(pb.Tracks) != 1 || filepath.Base(pb.Tracks[0]) != "b.wav" {
+		t.Errorf("want the bad file left out, got %v", pb.Tracks)
+	}
+	svc.ClearBadFiles()
+	if len(svc.BadFiles()) != 0 {
+		t.Error("ClearBadFiles: want an empty list")
+	}
+}
+
+func TestAllTracksFailingStopsWithError(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.wav", "b.wav", "c.wav"} {
+		writeCorrupt(t, filepath.Join(dir, name))
+	}
+	svc := newTestOutput()
+	start := time.Now()
+	svc.PlayFolder(dir, false, domain.ScanOptions{})
+
+	st := waitState(t, svc, func(st domain.AudioStatePayload) bool { return st.State == domain.AudioStopped })
+	if !strings.Contains(st.TrackInfo, "none of the 3 tracks") {
+		t.Errorf("unexpected info %q", st.TrackInfo)
+	}
+	if len(st.Errors) != 3 {
+		t.Errorf("want 3 track errors, got %+v", st.Errors)
+	}
+	if d := time.Since(start); d < retryDelay(1)+retryDelay(2) {
+		t.Errorf("want a back-off between failures, stopped after %v", d)
+	}
+}
+
+func TestRetryDelayBacksOff(t *testing.T) {
+	if retryDelay(1) != trackRetry || retryDelay(2) != 2*trackRetry {
+		t.Errorf("got %v, %v", retryDelay(1), retryDelay(2))
+	}
+	if retryDelay(100) != maxTrackRetry {
+		t.Errorf("want the delay capped, got %v", retryDelay(100))
+	}
+}
+
+func TestBadFilesPersistUntilChanged(t *testing.T) {
+	dir := t.TempDir()
+	track := filepath.Join(dir, "a.wav")
+	writeCorrupt(t, track)
+	list := filepath.Join(dir, "bad-files.json")
+	newBadFiles(list).add(track, os.ErrInvalid)
+	newBadFiles(list).add(filepath.Join(dir, "gone.wav"), os.ErrInvalid)
+
+	b := newBadFiles(list)
+	if !b.has(track) || len(b.list()) != 1 {
+		t.Fatalf("want only the existing file recorded, got %+v", b.list())
+	}
+	writeWAV(t, track)
+	if b.has(track) {
+		t.Error("a file that changed should be tried again")
+	}
+}
@@ -0,0 +1,46 @@
+package audio
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gopxl/beep"
+	"github.com/gopxl/beep/wav"
+)
+
+// DefaultChime is played when the configured chime name is unknown.
+const DefaultChime = "bell"
+
+//go:embed chimes/*.wav
+var chimeFS embed.FS
+
+// Chimes lists the names of the completion chimes embedded in the binary.
+func Chimes() []string {
+	entries, _ := chimeFS.ReadDir("chimes")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), path.Ext(e.Name())))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// decodeChime decodes an embedded chime, falling back to DefaultChime.
+func decodeChime(name string) (beep.Streamer, beep.Format, error) {
+	data, err := chimeFS.ReadFile("chimes/" + name + ".wav")
+	if err != nil {
+		data, err = chimeFS.ReadFile("chimes/" + DefaultChime + ".wav")
+	}
+	if err != nil {
+		return nil, beep.Format{}, fmt.Errorf("chime %q: %w", name, err)
+	}
+	streamer, format, err := wav.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, beep.Format{}, fmt.Errorf("chime %q: %w", name, err)
+	}
+	return streamer, format, nil
+}
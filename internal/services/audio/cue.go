@@ -0,0 +1,53 @@
+package audio
+
+import (
+	"math"
+	"time"
+
+	"github.com/gopxl/beep"
+)
+
+// Cue names accepted by PlayCue.
+const (
+	CueWarning = "warning" // two short pips before a segment ends
+)
+
+// cueRate is the sample rate cues are synthesised at before resampling to the output.
+const cueRate = beep.SampleRate(44100)
+
+// cueTone returns the synthesised streamer for a named cue, or nil if unknown.
+func cueTone(name string) beep.Streamer {
+	switch name {
+	case CueWarning:
+		return beep.Seq(
+			pip(880, 0.12),
+			beep.Silence(cueRate.N(80*time.Millisecond)),
+			pip(880, 0.12),
+		)
+	}
+	return nil
+}
+
+// pip is a sine tone with a short attack and release so it never clicks.
+func pip(freq, seconds float64) beep.Streamer {
+	total := int(seconds * float64(cueRate))
+	ramp := total / 8
+	pos := 0
+	return beep.StreamerFunc(func(samples [][2]float64) (n int, ok bool) {
+		for i := range samples {
+			if pos >= total {
+				return i, i > 0
+			}
+			env := 1.0
+			if pos < ramp {
+				env = float64(pos) / float64(ramp)
+			} else if pos > total-ramp {
+				env = float64(total-pos) / float64(ramp)
+			}
+			v := 0.4 * env * math.Sin(2*math.Pi*freq*float64(pos)/float64(cueRate))
+			samples[i] = [2]float64{v, v}
+			pos++
+		}
+		return len(samples), true
+	})
+}
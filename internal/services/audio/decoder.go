@@ -0,0 +1,114 @@
+package audio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gopxl/beep"
+	"github.com/gopxl/beep/flac"
+	"github.com/gopxl/beep/mp3"
+	"github.com/gopxl/beep/vorbis"
+	"github.com/gopxl/beep/wav"
+)
+
+// decoder is one entry of the format registry.
+type decoder struct {
+	name   string
+	exts   []string
+	magic  func(head []byte) bool // recognises the format from the file's first bytes
+	decode func(rc io.ReadCloser) (beep.StreamSeekCloser, beep.Format, error)
+}
+
+// decoders lists the formats with pure-Go decoders. MP3 comes last: its magic
+// (a bare frame sync) is the weakest, so the others get the first look.
+var decoders = []decoder{
+	{
+		name:   "WAV",
+		exts:   []string{".wav"},
+		magic:  func(h []byte) bool { return len(h) >= 12 && string(h[:4]) == "RIFF" && string(h[8:12]) == "WAVE" },
+		decode: func(rc io.ReadCloser) (beep.StreamSeekCloser, beep.Format, error) { return wav.Decode(rc) },
+	},
+	{
+		name:   "FLAC",
+		exts:   []string{".flac"},
+		magic:  func(h []byte) bool { return bytes.HasPrefix(h, []byte("fLaC")) },
+		decode: func(rc io.ReadCloser) (beep.StreamSeekCloser, beep.Format, error) { return flac.Decode(rc) },
+	},
+	{
+		name:   "Ogg Vorbis",
+		exts:   []string{".ogg", ".oga"},
+		magic:  func(h []byte) bool { return bytes.HasPrefix(h, []byte("OggS")) },
+		decode: vorbis.Decode,
+	},
+	{
+		name: "MP3",
+		exts: []string{".mp3"},
+		magic: func(h []byte) bool {
+			return bytes.HasPrefix(h, []byte("ID3")) || len(h) >= 2 && h[0] == 0xFF && h[1]&0xE0 == 0xE0
+		},
+		decode: mp3.Decode,
+	},
+}
+
+// SupportedExtensions lists every file extension the registry can decode, e.g. ".flac".
+func SupportedExtensions() []string {
+	var exts []string
+	for _, d := range decoders {
+		exts = append(exts, d.exts...)
+	}
+	return exts
+}
+
+// isSupported reports whether path has a decodable extension.
+func isSupported(path string) bool {
+	return decoderFor(path, nil) != nil
+}
+
+// decoderFor picks a decoder by magic bytes, falling back to the extension.
+// head may be nil to match on the extension only.
+func decoderFor(path string, head []byte) *decoder {
+	for i := range decoders {
+		if head != nil && decoders[i].magic(head) {
+			return &decoders[i]
+		}
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	for i := range decoders {
+		for _, e := range decoders[i].exts {
+			if e == ext {
+				return &decoders[i]
+			}
+		}
+	}
+	return nil
+}
+
+// decodeFile opens and decodes an audio file of any supported format. The
+// content decides the decoder, so a mislabelled file still plays.
+func decodeFile(path string) (beep.StreamSeekCloser, beep.Format, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, beep.Format{}, err
+	}
+	head := make([]byte, 12)
+	n, _ := io.ReadFull(f, head)
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, beep.Format{}, err
+	}
+	d := decoderFor(path, head[:n])
+	if d == nil {
+		f.Close()
+		return nil, beep.Format{}, fmt.Errorf("unsupported audio format: %s", filepath.Base(path))
+	}
+	st, format, err := d.decode(f)
+	if err != nil {
+		f.Close()
+		return nil, beep.Format{}, fmt.Errorf("%s: %w", d.name, err)
+	}
+	return st, format, nil
+}
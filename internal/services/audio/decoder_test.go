@@ -0,0 +1,102 @@
+package audio
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"focusplay/internal/domain"
+
+	"github.com/gopxl/beep"
+	"github.com/gopxl/beep/wav"
+)
+
+// writeWAV writes a short 22.05 kHz stereo tone to path.
+func writeWAV(t *testing.T, path string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	format := beep.Format{SampleRate: 22050, NumChannels: 2, Precision: 2}
+	if err := wav.Encode(f, beep.Take(2205, constant(0.5)), format); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecoderForSniffsMagic(t *testing.T) {
+	cases := []struct {
+		head []byte
+		want string
+	}{
+		{[]byte("RIFF\x24\x00\x00\x00WAVEfmt "), "WAV"},
+		{[]byte("fLaC\x00\x00\x00\x22"), "FLAC"},
+		{[]byte("OggS\x00\x02"), "Ogg Vorbis"},
+		{[]byte("ID3\x04\x00"), "MP3"},
+		{[]byte{0xFF, 0xFB, 0x90, 0x64}, "MP3"},
+	}
+	for _, c := range cases {
+		// The extension deliberately disagrees with the content.
+		if d := decoderFor("track.dat", c.head); d == nil || d.name != c.want {
+			t.Errorf("head %q: want %s, got %v", c.head, c.want, d)
+		}
+	}
+	if d := decoderFor("song.FLAC", []byte("????")); d == nil || d.name != "FLAC" {
+		t.Errorf("unknown content should fall back to the extension, got %v", d)
+	}
+	if d := decoderFor("clip.m4a", []byte("\x00\x00\x00\x20ftypM4A ")); d != nil {
+		t.Errorf("M4A has no pure-Go decoder, got %s", d.name)
+	}
+}
+
+func TestDecodeFileByContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mislabelled.mp3")
+	writeWAV(t, path)
+
+	st, format, err := decodeFile(path)
+	if err != nil {
+		t.Fatalf("decodeFile: %v", err)
+	}
+	defer st.Close()
+	if format.SampleRate != 22050 || st.Len() != 2205 {
+		t.Errorf("want 2205 samples at 22050 Hz, got %d at %d", st.Len(), format.SampleRate)
+	}
+}
+
+func TestDecodeFileUnsupported(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	os.WriteFile(path, []byte("not audio"), 0o644)
+	if _, _, err := decodeFile(path); err == nil {
+		t.Error("expected an error for a non-audio file")
+	}
+}
+
+func TestScanTracksFindsAllFormats(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.mp3", "b.FLAC", "c.ogg", "d.wav", "e.m4a", "cover.jpg"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0o644)
+	}
+	os.Mkdir(filepath.Join(dir, "sub.mp3"), 0o755)
+
+	tracks, _, err := scanTracks(dir, domain.ScanOptions{MaxDepth: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tr := range tracks {
+		names = append(names, filepath.Base(tr))
+	}
+	sort.Strings(names)
+	want := []string{"a.mp3", "b.FLAC", "c.ogg", "d.wav"}
+	if len(names) != len(want) {
+		t.Fatalf("want %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("want %v, got %v", want, names)
+			break
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package audio
+
+import "errors"
+
+// ErrNoDeviceChoice is returned where FocusPlay cannot choose the output device
+// itself and always plays to the system default: on Windows and macOS, and on
+// Linux without PulseAudio or PipeWire.
+var ErrNoDeviceChoice = errors.New("choosing the output device is not supported on this system; sound goes to the system default")
+
+// availableDevice returns id when that output device is connected, or ""
+// (the system default) when it is not.
+func availableDevice(id string) string {
+	if id == "" {
+		return ""
+	}
+	devices, _ := outputDevices()
+	for _, d := range devices {
+		if d.ID == id {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,127 @@
+package audio
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"focusplay/internal/domain"
+)
+
+// On Linux the speaker plays to ALSA's default device, which on desktops is
+// PulseAudio or PipeWire. Devices are their sinks, listed and switched with
+// pactl; without a sound server there is only the default.
+
+// pactl runs the PulseAudio command-line client with untranslated output.
+var pactl = func(args ...string) (string, error) {
+	cmd := exec.Command("pactl", args...)
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+func outputDevices() ([]domain.AudioDevice, error) {
+	out, err := pactl("list", "sinks")
+	if err != nil {
+		return nil, fmt.Errorf("%w (needs PulseAudio or PipeWire: %v)", ErrNoDeviceChoice, err)
+	}
+	devices := parseSinks(out)
+	if info, err := pactl("info"); err == nil {
+		def := field(info, "Default Sink: ")
+		for i := range devices {
+			devices[i].Default = devices[i].ID == def
+		}
+	}
+	return devices, nil
+}
+
+// routeOutput points the output at device id ("" for the default). Before
+// the output opens this steers the sound server through the environment;
+// once it is open, this process's streams are moved over.
+func routeOutput(id string, live bool) error {
+	if !live {
+		for _, env := range []string{"PULSE_SINK", "PIPEWIRE_NODE"} {
+			if id == "" {
+				os.Unsetenv(env)
+			} else {
+				os.Setenv(env, id)
+			}
+		}
+		return nil
+	}
+	target := id
+	if target == "" {
+		target = "@DEFAULT_SINK@"
+	}
+	out, err := pactl("list", "sink-inputs")
+	if err != nil {
+		return err
+	}
+	for _, input := range sinkInputsOf(out, os.Getpid()) {
+		if _, err := pactl("move-sink-input", input, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseSinks reads the sinks from `pactl list sinks`.
+func parseSinks(out string) []domain.AudioDevice {
+	var devices []domain.AudioDevice
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "Sink #"):
+			devices = append(devices, domain.AudioDevice{})
+		case len(devices) == 0:
+		case strings.HasPrefix(line, "\tName: "):
+			devices[len(devices)-1].ID = strings.TrimPrefix(line, "\tName: ")
+		case strings.HasPrefix(line, "\tDescription: "):
+			devices[len(devices)-1].Name = strings.TrimPrefix(line, "\tDescription: ")
+		}
+	}
+	kept := devices[:0]
+	for _, d := range devices {
+		if d.ID == "" {
+			continue
+		}
+		if d.Name == "" {
+			d.Name = d.ID
+		}
+		kept = append(kept, d)
+	}
+	return kept
+}
+
+// sinkInputsOf returns the indexes of the playback streams in
+// `pactl list sink-inputs` that belong to process pid.
+func sinkInputsOf(out string, pid int) []string {
+	var ids []string
+	current := ""
+	want := `application.process.id = "` + strconv.Itoa(pid) + `"`
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		line := sc.Text()
+		if id, ok := strings.CutPrefix(line, "Sink Input #"); ok {
+			current = id
+		} else if current != "" && strings.TrimSpace(line) == want {
+			ids = append(ids, current)
+			current = ""
+		}
+	}
+	return ids
+}
+
+// field returns the rest of the first line of out starting with prefix.
+func field(out, prefix string) string {
+	for _, line := range strings.Split(out, "\n") {
+		if v, ok := strings.CutPrefix(line, prefix); ok {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,136 @@
+package audio
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"focusplay/internal/domain"
+)
+
+const sinksOutput = `Sink #47
+	State: SUSPENDED
+	Name: alsa_output.pci-0000_00_1f.3.analog-stereo
+	Description: Built-in Audio Analog Stereo
+	Driver: PipeWire
+	Properties:
+		node.name = "alsa_output.pci-0000_00_1f.3.analog-stereo"
+
+Sink #63
+	State: RUNNING
+	Name: bluez_output.AA_BB_CC_DD_EE_FF.1
+	Description: WH-1000XM4
+	Driver: PipeWire
+`
+
+// fakePactl replaces pactl with canned output for the duration of a test
+// and records the commands it was given.
+func fakePactl(t *testing.T, outputs map[string]string) *[]string {
+	t.Helper()
+	var calls []string
+	old := pactl
+	pactl = func(args ...string) (string, error) {
+		cmd := strings.Join(args, " ")
+		calls = append(calls, cmd)
+		if out, ok := outputs[cmd]; ok {
+			return out, nil
+		}
+		if strings.HasPrefix(cmd, "move-sink-input") {
+			return "", nil
+		}
+		return "", errors.New("pactl: unexpected " + cmd)
+	}
+	t.Cleanup(func() { pactl = old })
+	return &calls
+}
+
+func TestOutputDevicesFromPactl(t *testing.T) {
+	fakePactl(t, map[string]string{
+		"list sinks": sinksOutput,
+		"info":       "Server Name: PulseAudio (on PipeWire 1.0.5)\nDefault Sink: bluez_output.AA_BB_CC_DD_EE_FF.1\n",
+	})
+	devices, err := outputDevices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []domain.AudioDevice{
+		{ID: "alsa_output.pci-0000_00_1f.3.analog-stereo", Name: "Built-in Audio Analog Stereo"},
+		{ID: "bluez_output.AA_BB_CC_DD_EE_FF.1", Name: "WH-1000XM4", Default: true},
+	}
+	if !reflect.DeepEqual(devices, want) {
+		t.Errorf("got %+v", devices)
+	}
+}
+
+func TestUnavailableDeviceFallsBackToDefault(t *testing.T) {
+	fakePactl(t, map[string]string{"list sinks": sinksOutput})
+	if got := availableDevice("bluez_output.AA_BB_CC_DD_EE_FF.1"); got != "bluez_output.AA_BB_CC_DD_EE_FF.1" {
+		t.Errorf("connected device: got %q", got)
+	}
+	if got := availableDevice("usb_headset"); got != "" {
+		t.Errorf("disconnected device: want the default, got %q", got)
+	}
+
+	fakePactl(t, nil) // no sound server
+	if got := availableDevice("usb_headset"); got != "" {
+		t.Errorf("without pactl: want the default, got %q", got)
+	}
+}
+
+func TestNoSoundServerMeansNoDeviceChoice(t *testing.T) {
+	fakePactl(t, nil)
+	if _, err := outputDevices(); !errors.Is(err, ErrNoDeviceChoice) {
+		t.Errorf("devices: want ErrNoDeviceChoice, got %v", err)
+	}
+	sink := NewSpeakerSink()
+	if err := sink.SetDevice("usb_headset"); !errors.Is(err, ErrNoDeviceChoice) {
+		t.Errorf("SetDevice: want ErrNoDeviceChoice, got %v", err)
+	}
+	if err := sink.SetDevice(""); err != nil {
+		t.Errorf("the default needs no sound server, got %v", err)
+	}
+}
+
+func TestRouteOutputBeforeOpen(t *testing.T) {
+	t.Setenv("PULSE_SINK", "")
+	t.Setenv("PIPEWIRE_NODE", "")
+	routeOutput("usb_headset", false)
+	if got := os.Getenv("PULSE_SINK"); got != "usb_headset" {
+		t.Errorf("PULSE_SINK: got %q", got)
+	}
+	routeOutput("", false)
+	if _, set := os.LookupEnv("PULSE_SINK"); set {
+		t.Error("the default device should clear PULSE_SINK")
+	}
+}
+
+func TestRouteOutputMovesOwnStreams(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	calls := fakePactl(t, map[string]string{"list sink-inputs": `Sink Input #12
+	Driver: PipeWire
+	Properties:
+		application.name = "Firefox"
+		application.process.id = "999999999"
+Sink Input #15
+	Driver: PipeWire
+	Properties:
+		application.name = "focusplay"
+		application.process.id = "` + pid + `"
+`})
+	if err := routeOutput("usb_headset", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := routeOutput("", true); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"list sink-inputs", "move-sink-input 15 usb_headset",
+		"list sink-inputs", "move-sink-input 15 @DEFAULT_SINK@",
+	}
+	if !reflect.DeepEqual(*calls, want) {
+		t.Errorf("got %q", *calls)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+
+package audio
+
+import "focusplay/internal/domain"
+
+// Elsewhere the speaker always plays to the system default device, which the
+// user picks in the OS sound settings: the output library has no way to pick
+// another.
+
+func outputDevices() ([]domain.AudioDevice, error) {
+	return nil, ErrNoDeviceChoice
+}
+
+func routeOutput(id string, live bool) error {
+	if id != "" {
+		return ErrNoDeviceChoice
+	}
+	return nil
+}
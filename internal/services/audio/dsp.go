@@ -0,0 +1,161 @@
+package audio
+
+import (
+	"math"
+
+	"focusplay/internal/domain"
+
+	"github.com/gopxl/beep"
+)
+
+// EQ band centres and limits. The bass and treble bands are shelves, the mid
+// band a bell; gains beyond ±maxEQGain and cut-offs outside the audible range
+// are clamped.
+const (
+	bassHz    = 250
+	midHz     = 1000
+	trebleHz  = 4000
+	maxEQGain = 12
+	minCutHz  = 20
+	maxCutHz  = 20000
+)
+
+// muffleHz is the cut-off of the muffle low-pass: voices and melody stay
+// recognisable, as if the music were playing in the next room.
+const muffleHz = 500
+
+// butterworthQ gives the flattest pass band for the filters and shelves.
+const butterworthQ = math.Sqrt2 / 2
+
+// octaveQ makes a peaking filter one octave wide between its half-gain points.
+const octaveQ = math.Sqrt2
+
+// biquad is a second-order IIR filter section with normalised coefficients
+// (a0 = 1) and its own state, for one channel.
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// newBiquad normalises the coefficients of the RBJ audio EQ cookbook.
+func newBiquad(b0, b1, b2, a0, a1, a2 float64) biquad {
+	return biquad{b0: b0 / a0, b1: b1 / a0, b2: b2 / a0, a1: a1 / a0, a2: a2 / a0}
+}
+
+// cookbook returns cos(w0) and alpha for a filter at hz with quality q.
+func cookbook(fs, hz, q float64) (cos, alpha float64) {
+	w0 := 2 * math.Pi * hz / fs
+	return math.Cos(w0), math.Sin(w0) / (2 * q)
+}
+
+func lowPass(fs, hz float64) biquad {
+	cos, alpha := cookbook(fs, hz, butterworthQ)
+	return newBiquad((1-cos)/2, 1-cos, (1-cos)/2, 1+alpha, -2*cos, 1-alpha)
+}
+
+func highPass(fs, hz float64) biquad {
+	cos, alpha := cookbook(fs, hz, butterworthQ)
+	return newBiquad((1+cos)/2, -(1 + cos), (1+cos)/2, 1+alpha, -2*cos, 1-alpha)
+}
+
+// peaking boosts or cuts db around hz, an octave wide.
+func peaking(fs, hz, db float64) biquad {
+	cos, alpha := cookbook(fs, hz, octaveQ)
+	a := math.Pow(10, db/40)
+	return newBiquad(1+alpha*a, -2*cos, 1-alpha*a, 1+alpha/a, -2*cos, 1-alpha/a)
+}
+
+// lowShelf boosts or cuts db below hz.
+func lowShelf(fs, hz, db float64) biquad {
+	cos, alpha := cookbook(fs, hz, butterworthQ)
+	a := math.Pow(10, db/40)
+	k := 2 * math.Sqrt(a) * alpha
+	return newBiquad(
+		a*((a+1)-(a-1)*cos+k), 2*a*((a-1)-(a+1)*cos), a*((a+1)-(a-1)*cos-k),
+		(a+1)+(a-1)*cos+k, -2*((a-1)+(a+1)*cos), (a+1)+(a-1)*cos-k,
+	)
+}
+
+// highShelf boosts or cuts db above hz.
+func highShelf(fs, hz, db float64) biquad {
+	cos, alpha := cookbook(fs, hz, butterworthQ)
+	a := math.Pow(10, db/40)
+	k := 2 * math.Sqrt(a) * alpha
+	return newBiquad(
+		a*((a+1)+(a-1)*cos+k), -2*a*((a-1)+(a+1)*cos), a*((a+1)+(a-1)*cos-k),
+		(a+1)-(a-1)*cos+k, 2*((a-1)-(a+1)*cos), (a+1)-(a-1)*cos-k,
+	)
+}
+
+// filterStages designs eq as a series of biquads at sample rate sr, leaving
+// out the filters and bands that are off. The zero EQ has no stages.
+func filterStages(eq domain.EQ, sr beep.SampleRate) []biquad {
+	fs := float64(sr)
+	cut := func(hz int) float64 {
+		return float64(max(minCutHz, min(hz, maxCutHz, int(fs/2)-1)))
+	}
+	gain := func(db float64) float64 {
+		return max(-maxEQGain, min(db, maxEQGain))
+	}
+	var stages []biquad
+	if eq.HighPassHz > 0 {
+		stages = append(stages, highPass(fs, cut(eq.HighPassHz)))
+	}
+	if eq.BassDB != 0 {
+		stages = append(stages, lowShelf(fs, bassHz, gain(eq.BassDB)))
+	}
+	if eq.MidDB != 0 {
+		stages = append(stages, peaking(fs, midHz, gain(eq.MidDB)))
+	}
+	if eq.TrebleDB != 0 {
+		stages = append(stages, highShelf(fs, trebleHz, gain(eq.TrebleDB)))
+	}
+	if eq.LowPassHz > 0 {
+		stages = append(stages, lowPass(fs, cut(eq.LowPassHz)))
+	}
+	return stages
+}
+
+// muffleStages is a steep low-pass at muffleHz: two Butterworth sections.
+func muffleStages(sr beep.SampleRate) []biquad {
+	return []biquad{lowPass(float64(sr), muffleHz), lowPass(float64(sr), muffleHz)}
+}
+
+// filterChain runs a stereo stream through a series of biquads, each with
+// its own state per channel. With no stages the sound passes through as is.
+type filterChain struct {
+	Streamer beep.Streamer
+	stages   [][2]biquad
+}
+
+// set replaces the stages. Call with the sink locked once playing; the new
+// filters start from silence, which is inaudible next to a change of tone.
+func (f *filterChain) set(stages []biquad) {
+	f.stages = f.stages[:0]
+	for _, b := range stages {
+		f.stages = append(f.stages, [2]biquad{b, b})
+	}
+}
+
+func (f *filterChain) Stream(samples [][2]float64) (int, bool) {
+	n, ok := f.Streamer.Stream(samples)
+	for i := range f.stages {
+		st := &f.stages[i]
+		for j := range samples[:n] {
+			samples[j][0] = st[0].process(samples[j][0])
+			samples[j][1] = st[1].process(samples[j][1])
+		}
+	}
+	return n, ok
+}
+
+func (f *filterChain) Err() error {
+	return f.Streamer.Err()
+}
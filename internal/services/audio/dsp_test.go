@@ -0,0 +1,97 @@
+package audio
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"focusplay/internal/domain"
+)
+
+// gain returns how much the stages pass of a sine at hz, in dB, measured
+// after the filters have settled.
+func gain(stages []biquad, hz float64) float64 {
+	f := filterChain{Streamer: sine(hz, 0.5)}
+	f.set(stages)
+	buf := make([][2]float64, outputRate.N(200*time.Millisecond))
+	f.Stream(buf)
+	f.Stream(buf)
+	var sum float64
+	for _, s := range buf {
+		sum += s[0] * s[0]
+	}
+	return 20 * math.Log10(math.Sqrt(sum/float64(len(buf)))/(0.5/math.Sqrt2))
+}
+
+func TestFilterStagesShapeTheSpectrum(t *testing.T) {
+	cases := []struct {
+		name string
+		eq   domain.EQ
+		hz   float64
+		want float64 // dB, ±1
+	}{
+		{"low-pass passes the bass", domain.EQ{LowPassHz: 1000}, 100, 0},
+		{"low-pass at its cut-off", domain.EQ{LowPassHz: 1000}, 1000, -3},
+		{"low-pass cuts the treble", domain.EQ{LowPassHz: 1000}, 4000, -24},
+		{"high-pass cuts the bass", domain.EQ{HighPassHz: 1000}, 250, -24},
+		{"high-pass passes the treble", domain.EQ{HighPassHz: 1000}, 8000, 0},
+		{"bass shelf", domain.EQ{BassDB: 6}, 40, 6},
+		{"bass shelf leaves the treble", domain.EQ{BassDB: 6}, 8000, 0},
+		{"mid bell", domain.EQ{MidDB: -6}, 1000, -6},
+		{"mid bell is an octave wide", domain.EQ{MidDB: -6}, 1414, -3},
+		{"treble shelf", domain.EQ{TrebleDB: -6}, 16000, -6},
+		{"gains are clamped", domain.EQ{TrebleDB: -40}, 16000, -maxEQGain},
+	}
+	for _, c := range cases {
+		if got := gain(filterStages(c.eq, outputRate), c.hz); math.Abs(got-c.want) > 1 {
+			t.Errorf("%s: %g Hz at %.1f dB, want %g", c.name, c.hz, got, c.want)
+		}
+	}
+	if stages := filterStages(domain.EQ{}, outputRate); len(stages) != 0 {
+		t.Errorf("the zero EQ should have no stages, got %d", len(stages))
+	}
+}
+
+func TestFilterChainWithoutStagesPassesThrough(t *testing.T) {
+	f := filterChain{Streamer: constant(0.25)}
+	buf := make([][2]float64, 16)
+	if n, ok := f.Stream(buf); n != 16 || !ok || buf[15] != [2]float64{0.25, 0.25} {
+		t.Errorf("got %d, %v, %v", n, ok, buf[15])
+	}
+}
+
+func TestMuffleLowPassesMusicButNotCues(t *testing.T) {
+	svc := newTestOutput()
+	svc.SetEQ(domain.EQ{BassDB: 3})
+	music := svc.addStream(svc.channels[ChannelMusic], sine(4000, 0.5), outputRate)
+	before := rms(svc, 4096)
+
+	log := &eventLog{}
+	svc.SetEmitter(log)
+	svc.SetMuffle(true)
+	rms(svc, 4096) // let the filters settle
+	if after := rms(svc, 4096); after > before/30 {
+		t.Errorf("muffled 4 kHz: rms %.4f, was %.4f", after, before)
+	}
+	states := log.events["audioStateChanged"]
+	if len(states) != 1 || !states[0].(domain.AudioStatePayload).Muffled || !svc.GetState().Muffled {
+		t.Errorf("want one muffled state, got %+v", states)
+	}
+
+	// Cues stay clear.
+	svc.removeStream(music)
+	svc.addStream(svc.channels[ChannelCue], sine(4000, 0.5), outputRate)
+	if got := rms(svc, 4096); math.Abs(got-before) > before/10 {
+		t.Errorf("cue while muffled: rms %.4f, want %.4f", got, before)
+	}
+
+	// Turning it off keeps the phase EQ.
+	svc.SetMuffle(false)
+	if got := len(svc.channels[ChannelMusic].filters.stages); got != 1 {
+		t.Errorf("unmuffled: want the bass shelf alone, got %d stages", got)
+	}
+	states = log.events["audioStateChanged"]
+	if svc.Muffled() || len(states) != 2 || states[1].(domain.AudioStatePayload).Muffled {
+		t.Errorf("want an unmuffled state, got %+v", states)
+	}
+}
@@ -0,0 +1,140 @@
+package audio
+
+import (
+	"time"
+
+	"github.com/gopxl/beep"
+)
+
+// stopFade is how long Stop takes to silence the music, just enough to avoid a click.
+const stopFade = 300 * time.Millisecond
+
+// run is one playback on a channel (a file, folder, generator or soundscape).
+// stop closes done; fade is written first, so anything woken by done sees how
+// long to fade out over.
+type run struct {
+	done chan struct{}
+	fade time.Duration
+	skip chan int // track steps (+1 next, −1 previous); nil unless the run plays tracks
+}
+
+func newRun() *run { return &run{done: make(chan struct{})} }
+
+// newTrackRun returns a run that NextTrack and PreviousTrack can step through.
+func newTrackRun() *run {
+	r := newRun()
+	r.skip = make(chan int, 1)
+	return r
+}
+
+func (r *run) stop(fade time.Duration) {
+	r.fade = fade
+	close(r.done)
+}
+
+func (r *run) stopped() bool {
+	select {
+	case <-r.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// fader applies a linear gain ramp to a stream at outputRate. It fades in on
+// start, fades out on demand or at a preset position (for crossfades), and
+// drains itself once silent so the mixer drops it. Mutate it with the sink locked.
+type fader struct {
+	st     beep.Streamer
+	gain   float64
+	target float64
+	step   float64 // gain change per sample while ramping
+	pos    int
+	start  time.Duration // position in the source where the stream began
+	length time.Duration // length of the whole source, 0 when unknown
+	outAt  int           // position that starts the automatic fade-out, −1 for none
+	outLen int
+	ending bool // drain once the fade-out reaches silence
+
+	fading chan struct{} // closed when the fade-out starts
+	ended  chan struct{} // closed when the stream has finished
+	closed bool
+}
+
+// newFader wraps st, ramping in from silence over fadeIn samples.
+func newFader(st beep.Streamer, fadeIn int) *fader {
+	f := &fader{
+		st:     st,
+		gain:   1,
+		target: 1,
+		outAt:  -1,
+		fading: make(chan struct{}),
+		ended:  make(chan struct{}),
+	}
+	if fadeIn > 0 {
+		f.gain = 0
+		f.step = 1 / float64(fadeIn)
+	}
+	return f
+}
+
+// fadeOutAt schedules a fade-out of n samples starting at position at.
+func (f *fader) fadeOutAt(at, n int) {
+	f.outAt = max(at, 0)
+	f.outLen = n
+}
+
+// fadeOut ramps from the current gain to silence over n samples, then ends the stream.
+func (f *fader) fadeOut(n int) {
+	if !f.ending {
+		f.ending = true
+		close(f.fading)
+	}
+	f.target = 0
+	if n <= 0 {
+		f.gain = 0
+		return
+	}
+	f.step = f.gain / float64(n)
+}
+
+func (f *fader) Stream(samples [][2]float64) (int, bool) {
+	if f.closed {
+		return 0, false
+	}
+	n, ok := f.st.Stream(samples)
+	for i := 0; i < n; i++ {
+		if f.pos == f.outAt {
+			f.fadeOut(f.outLen)
+		}
+		f.pos++
+		if f.ending && f.gain <= 0 {
+			// Streamers report the end with n == 0, so samples already
+			// faded go out now and the end follows on the next call.
+			f.finish()
+			return i, i > 0
+		}
+		samples[i][0] *= f.gain
+		samples[i][1] *= f.gain
+		switch {
+		case f.gain < f.target:
+			f.gain = min(f.gain+f.step, f.target)
+		case f.gain > f.target:
+			f.gain = max(f.gain-f.step, f.target)
+		}
+	}
+	if !ok {
+		f.finish()
+	}
+	return n, ok
+}
+
+func (f *fader) Err() error { return f.st.Err() }
+
+// finish marks the stream ended; it never reads its source again.
+func (f *fader) finish() {
+	if !f.closed {
+		f.closed = true
+		close(f.ended)
+	}
+}
@@ -0,0 +1,146 @@
+package audio
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"focusplay/internal/domain"
+
+	"github.com/gopxl/beep"
+)
+
+func TestFaderRampsIn(t *testing.T) {
+	f := newFader(constant(1), 100)
+	buf := make([][2]float64, 200)
+	f.Stream(buf)
+	if buf[0][0] != 0 {
+		t.Errorf("first sample: want 0, got %.3f", buf[0][0])
+	}
+	if math.Abs(buf[50][0]-0.5) > 0.02 {
+		t.Errorf("halfway: want 0.5, got %.3f", buf[50][0])
+	}
+	if buf[150][0] != 1 {
+		t.Errorf("after the ramp: want 1, got %.3f", buf[150][0])
+	}
+}
+
+func TestFaderScheduledFadeOutEnds(t *testing.T) {
+	f := newFader(constant(1), 0)
+	f.fadeOutAt(100, 50)
+	buf := make([][2]float64, 400)
+	n, ok := f.Stream(buf)
+	if !ok || n >= 200 {
+		t.Fatalf("want the stream to end after ~150 samples, got n=%d ok=%v", n, ok)
+	}
+	if n, ok := f.Stream(make([][2]float64, 10)); n != 0 || ok {
+		t.Errorf("after the fade: want 0, false, got %d, %v", n, ok)
+	}
+	if buf[99][0] != 1 || math.Abs(buf[125][0]-0.5) > 0.03 {
+		t.Errorf("want full level until 100 then a ramp, got %.3f / %.3f", buf[99][0], buf[125][0])
+	}
+	select {
+	case <-f.fading:
+	default:
+		t.Error("fading not signalled")
+	}
+	select {
+	case <-f.ended:
+	default:
+		t.Error("ended not signalled")
+	}
+}
+
+func TestFaderFadeOutAcrossBuffers(t *testing.T) {
+	f := newFader(constant(1), 0)
+	f.fadeOutAt(100, 50)
+	buf := make([][2]float64, 128)
+	var got []int
+	total := 0
+	for i := 0; i < 5; i++ {
+		n, ok := f.Stream(buf)
+		if !ok {
+			if n != 0 {
+				t.Errorf("call %d: ok == false with n = %d", i, n)
+			}
+			break
+		}
+		got = append(got, n)
+		total += n
+	}
+	if len(got) != 2 || got[0] != 128 || total < 149 || total > 151 {
+		t.Errorf("want 128 samples then the rest of the ~150 before the end, got %v", got)
+	}
+}
+
+// manualSink opens without pulling anything; the tests pull the master mixer themselves.
+type manualSink struct{ sync.Mutex }
+
+func (*manualSink) Open(beep.Streamer, beep.SampleRate) error { return nil }
+func (*manualSink) Close() error                              { return nil }
+
+// newTestOutput returns a Service that behaves as if the output device were open;
+// tests pull the master mixer by hand.
+func newTestOutput() *Service {
+	svc := New("")
+	svc.SetVolume(100)
+	svc.SetSink(&manualSink{})
+	return svc
+}
+
+func musicStreams(svc *Service) int {
+	svc.sink.Lock()
+	defer svc.sink.Unlock()
+	return svc.channels[ChannelMusic].mixer.Len()
+}
+
+func TestMusicFadesInFromSilence(t *testing.T) {
+	svc := newTestOutput()
+	svc.SetFades(100*time.Millisecond, 0)
+	svc.PlayNoise(NoiseWhite)
+
+	if got := rms(svc, outputRate.N(10*time.Millisecond)); got > 0.03 {
+		t.Errorf("start of fade-in should be near silent, got rms %.4f", got)
+	}
+	pull(svc, outputRate.N(100*time.Millisecond))
+	if got := rms(svc, 4096); got < 0.1 {
+		t.Errorf("after fade-in: want full level, got rms %.4f", got)
+	}
+}
+
+func TestReplacingMusicCrossfades(t *testing.T) {
+	svc := newTestOutput()
+	svc.SetFades(0, 200*time.Millisecond)
+	svc.PlayNoise(NoiseWhite)
+	pull(svc, 1024)
+
+	svc.PlayTone(ToneSpec{Mode: ToneBinaural, Carrier: 200, Beat: 10, Wave: WaveSine})
+	time.Sleep(20 * time.Millisecond) // let the old run start its fade-out
+	if n := musicStreams(svc); n != 2 {
+		t.Fatalf("during the crossfade both sources should play, got %d streams", n)
+	}
+	pull(svc, outputRate.N(250*time.Millisecond))
+	if n := musicStreams(svc); n != 1 {
+		t.Errorf("after the crossfade only the new source should remain, got %d streams", n)
+	}
+}
+
+func TestFadeOutSilencesMusic(t *testing.T) {
+	svc := newTestOutput()
+	svc.PlayNoise(NoisePink)
+	pull(svc, 1024)
+
+	svc.FadeOut(time.Second)
+	time.Sleep(20 * time.Millisecond)
+	if got := rms(svc, outputRate.N(100*time.Millisecond)); got == 0 {
+		t.Error("music should still be audible early in the fade-out")
+	}
+	pull(svc, outputRate.N(time.Second))
+	if got := rms(svc, 4096); got != 0 || musicStreams(svc) != 0 {
+		t.Errorf("after the fade-out: want silence and no streams, got rms %.4f, %d streams", got, musicStreams(svc))
+	}
+	if st := svc.GetState(); st.State != domain.AudioStopped {
+		t.Errorf("want stopped state, got %q", st.State)
+	}
+}
@@ -0,0 +1,292 @@
+package audio
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"focusplay/internal/infra/storage"
+
+	"github.com/dhowden/tag"
+	"github.com/gopxl/beep"
+)
+
+// targetLUFS is the loudness tracks are brought to when normalising, the
+// ReplayGain 2.0 reference level.
+const targetLUFS = -18.0
+
+// maxBoostDB bounds the correction, so a near-silent track is not blown up to full level.
+const maxBoostDB = 12.0
+
+// loudness finds a gain per file that brings it to targetLUFS: from its
+// ReplayGain tags when it has them, otherwise by measuring it once in the
+// background and caching the result in loudness.json. A file that has not
+// been measured yet plays unchanged.
+type loudness struct {
+	mu      sync.Mutex
+	path    string // "" keeps the cache in memory only
+	enabled bool
+	entries map[string]gainEntry
+	pending map[string]bool
+	queue   chan string
+	start   sync.Once
+}
+
+type gainEntry struct {
+	GainDB  float64 `json:"gainDb"`
+	Size    int64   `json:"size"`
+	ModTime int64   `json:"modTime"` // Unix ns; a changed file is measured again
+}
+
+func newLoudness(filePath string) *loudness {
+	ln := &loudness{
+		path:    filePath,
+		pending: map[string]bool{},
+		queue:   make(chan string, 4096),
+	}
+	if filePath != "" {
+		_ = storage.Load(filePath, &ln.entries)
+	}
+	if ln.entries == nil {
+		ln.entries = map[string]gainEntry{}
+	}
+	return ln
+}
+
+func (ln *loudness) setEnabled(on bool) {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+	ln.enabled = on
+}
+
+// gain returns the linear gain for path, 1 when normalisation is off or the
+// file has not been measured; in that case it is queued for measuring.
+func (ln *loudness) gain(path string) float64 {
+	ln.mu.Lock()
+	enabled := ln.enabled
+	ln.mu.Unlock()
+	if !enabled {
+		return 1
+	}
+	if db, ok := readReplayGain(path); ok {
+		return dbToGain(db)
+	}
+	if db, ok := ln.cached(path); ok {
+		return dbToGain(db)
+	}
+	ln.prepare([]string{path})
+	return 1
+}
+
+// prepare queues files for measuring ahead of time, e.g. a whole folder as it starts.
+func (ln *loudness) prepare(paths []string) {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+	if !ln.enabled {
+		return
+	}
+	ln.start.Do(func() { go ln.worker() })
+	for _, p := range paths {
+		if ln.pending[p] {
+			continue
+		}
+		select {
+		case ln.queue <- p:
+			ln.pending[p] = true
+		default: // queue full; it is picked up when it plays
+		}
+	}
+}
+
+func (ln *loudness) cached(path string) (float64, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+	e, ok := ln.entries[path]
+	if !ok || e.Size != info.Size() || e.ModTime != info.ModTime().UnixNano() {
+		return 0, false
+	}
+	return e.GainDB, true
+}
+
+// worker measures queued files one at a time, saving the cache whenever the queue runs dry.
+func (ln *loudness) worker() {
+	for path := range ln.queue {
+		if _, ok := readReplayGain(path); !ok {
+			if _, ok := ln.cached(path); !ok {
+				ln.measure(path)
+			}
+		}
+		ln.mu.Lock()
+		delete(ln.pending, path)
+		if len(ln.queue) == 0 && ln.path != "" {
+			_ = storage.Save(ln.path, ln.entries)
+		}
+		ln.mu.Unlock()
+	}
+}
+
+// measure decodes path and records the gain that brings it to targetLUFS.
+// Files that cannot be decoded are skipped.
+func (ln *loudness) measure(path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	streamer, format, err := decodeFile(path)
+	if err != nil {
+		return
+	}
+	lufs, peak := integratedLoudness(streamer, format.SampleRate)
+	streamer.Close()
+	if math.IsInf(lufs, -1) {
+		return // silent
+	}
+	db := targetLUFS - lufs
+	if peak > 0 {
+		db = min(db, -20*math.Log10(peak)) // never boost into clipping
+	}
+	ln.mu.Lock()
+	ln.entries[path] = gainEntry{GainDB: db, Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+	ln.mu.Unlock()
+}
+
+// readReplayGain returns the track gain from ReplayGain tags (Vorbis
+// comments or ID3v2 TXXX frames), lowered if needed so the tagged peak does not clip.
+func readReplayGain(path string) (float64, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	m, err := tag.ReadFrom(f)
+	if err != nil {
+		return 0, false
+	}
+	fields := map[string]string{}
+	for k, v := range m.Raw() {
+		switch v := v.(type) {
+		case string:
+			fields[strings.ToLower(k)] = v
+		case *tag.Comm:
+			fields[strings.ToLower(v.Description)] = v.Text
+		}
+	}
+	db, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(fields["replaygain_track_gain"]), "dB")), 64)
+	if err != nil {
+		return 0, false
+	}
+	if peak, err := strconv.ParseFloat(strings.TrimSpace(fields["replaygain_track_peak"]), 64); err == nil && peak > 0 {
+		db = min(db, -20*math.Log10(peak))
+	}
+	return db, true
+}
+
+func dbToGain(db float64) float64 {
+	return math.Pow(10, min(db, maxBoostDB)/20)
+}
+
+// integratedLoudness measures st to its end per ITU-R BS.1770: K-weighted,
+// in 400 ms blocks overlapping by 75 %, gated at −70 LUFS and then 10 LU
+// below the ungated level. It also returns the sample peak. Silence gives −Inf.
+func integratedLoudness(st beep.Streamer, sr beep.SampleRate) (lufs, peak float64) {
+	var filters [2]kWeighting
+	for c := range filters {
+		filters[c] = newKWeighting(float64(sr))
+	}
+	step := sr.N(100 * time.Millisecond)
+	var quarters []float64 // mean square of each 100 ms, both channels summed
+	var sum float64
+	n := 0
+	buf := make([][2]float64, 4096)
+	for {
+		got, ok := st.Stream(buf)
+		for _, s := range buf[:got] {
+			for c := 0; c < 2; c++ {
+				peak = max(peak, math.Abs(s[c]))
+				y := filters[c].process(s[c])
+				sum += y * y
+			}
+			if n++; n == step {
+				quarters = append(quarters, sum/float64(step))
+				sum, n = 0, 0
+			}
+		}
+		if !ok {
+			break
+		}
+	}
+
+	var blocks []float64
+	for i := 3; i < len(quarters); i++ {
+		blocks = append(blocks, (quarters[i-3]+quarters[i-2]+quarters[i-1]+quarters[i])/4)
+	}
+	gated := func(threshold float64) float64 {
+		var total float64
+		count := 0
+		for _, z := range blocks {
+			if blockLoudness(z) > threshold {
+				total += z
+				count++
+			}
+		}
+		if count == 0 {
+			return math.Inf(-1)
+		}
+		return blockLoudness(total / float64(count))
+	}
+	ungated := gated(-70)
+	if math.IsInf(ungated, -1) {
+		return ungated, peak
+	}
+	return gated(ungated - 10), peak
+}
+
+func blockLoudness(z float64) float64 {
+	return -0.691 + 10*math.Log10(z)
+}
+
+// kWeighting is the BS.1770 pre-filter: a high shelf modelling the head
+// followed by a high-pass, as two biquads designed for the sample rate.
+type kWeighting struct {
+	stages [2]biquad
+}
+
+func newKWeighting(fs float64) kWeighting {
+	// High shelf, +4 dB above ~1.7 kHz.
+	k := math.Tan(math.Pi * 1681.974450955533 / fs)
+	q := 0.7071752369554196
+	vh := math.Pow(10, 3.999843853973347/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf := biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+	// High-pass at ~38 Hz.
+	k = math.Tan(math.Pi * 38.13547087602444 / fs)
+	q = 0.5003270373238773
+	a0 = 1 + k/q + k*k
+	highPass := biquad{
+		b0: 1, b1: -2, b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+	return kWeighting{stages: [2]biquad{shelf, highPass}}
+}
+
+func (w *kWeighting) process(x float64) float64 {
+	for i := range w.stages {
+		x = w.stages[i].process(x)
+	}
+	return x
+}
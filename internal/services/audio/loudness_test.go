@@ -0,0 +1,126 @@
+package audio
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gopxl/beep"
+	"github.com/gopxl/beep/wav"
+)
+
+// sine streams a stereo sine wave at outputRate.
+func sine(hz, amp float64) beep.Streamer {
+	i := 0
+	return beep.StreamerFunc(func(samples [][2]float64) (int, bool) {
+		for j := range samples {
+			v := amp * math.Sin(2*math.Pi*hz*float64(i)/float64(outputRate))
+			samples[j] = [2]float64{v, v}
+			i++
+		}
+		return len(samples), true
+	})
+}
+
+func TestIntegratedLoudnessOfSine(t *testing.T) {
+	// A full-scale 997 Hz stereo sine reads 0 LUFS, so amplitude 0.1 is −20 LUFS.
+	lufs, peak := integratedLoudness(beep.Take(outputRate.N(5*time.Second), sine(997, 0.1)), outputRate)
+	if math.Abs(lufs+20) > 0.2 {
+		t.Errorf("want −20 LUFS, got %.2f", lufs)
+	}
+	if math.Abs(peak-0.1) > 1e-3 {
+		t.Errorf("want peak 0.1, got %.4f", peak)
+	}
+}
+
+func TestIntegratedLoudnessGatesSilence(t *testing.T) {
+	st := beep.Seq(
+		beep.Take(outputRate.N(3*time.Second), sine(997, 0.1)),
+		beep.Silence(outputRate.N(3*time.Second)),
+	)
+	if lufs, _ := integratedLoudness(st, outputRate); math.Abs(lufs+20) > 0.3 {
+		t.Errorf("silence should be gated out: want −20 LUFS, got %.2f", lufs)
+	}
+	if lufs, _ := integratedLoudness(beep.Silence(outputRate.N(time.Second)), outputRate); !math.IsInf(lufs, -1) {
+		t.Errorf("silence: want −Inf, got %.2f", lufs)
+	}
+}
+
+func TestReadReplayGain(t *testing.T) {
+	dir := t.TempDir()
+	flac := filepath.Join(dir, "a.flac")
+	writeFLACTags(t, flac, "REPLAYGAIN_TRACK_GAIN=-6.50 dB", "REPLAYGAIN_TRACK_PEAK=0.98")
+	if db, ok := readReplayGain(flac); !ok || db != -6.5 {
+		t.Errorf("Vorbis comment: want −6.5 dB, got %.2f (%v)", db, ok)
+	}
+
+	loud := filepath.Join(dir, "b.flac")
+	writeFLACTags(t, loud, "REPLAYGAIN_TRACK_GAIN=+3.00 dB", "REPLAYGAIN_TRACK_PEAK=0.9")
+	if db, _ := readReplayGain(loud); math.Abs(db-0.915) > 0.01 {
+		t.Errorf("boost should stop at the peak: want 0.92 dB, got %.2f", db)
+	}
+
+	mp3 := filepath.Join(dir, "c.mp3")
+	os.WriteFile(mp3, id3v23([2]string{"TXXX", "\x00REPLAYGAIN_TRACK_GAIN\x00-3.20 dB"}), 0o644)
+	if db, ok := readReplayGain(mp3); !ok || db != -3.2 {
+		t.Errorf("ID3 TXXX: want −3.2 dB, got %.2f (%v)", db, ok)
+	}
+
+	if _, ok := readReplayGain(filepath.Join(dir, "missing.flac")); ok {
+		t.Error("missing file: want no gain")
+	}
+}
+
+func TestMeasureCachesGain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quiet.wav")
+	f, _ := os.Create(path)
+	format := beep.Format{SampleRate: outputRate, NumChannels: 2, Precision: 2}
+	if err := wav.Encode(f, beep.Take(outputRate.N(2*time.Second), sine(997, 0.05)), format); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	// beep's WAV decoder reads 16-bit files at half scale, so take the level it decodes to.
+	st, _, _ := decodeFile(path)
+	_, peak := integratedLoudness(st, outputRate)
+	st.Close()
+	want := targetLUFS - 20*math.Log10(peak)
+
+	ln := newLoudness("")
+	ln.setEnabled(true)
+	ln.measure(path)
+	db, ok := ln.cached(path)
+	if !ok || math.Abs(db-want) > 0.3 {
+		t.Fatalf("want %.2f dB, got %.2f (%v)", want, db, ok)
+	}
+	if g := ln.gain(path); math.Abs(g-dbToGain(db)) > 1e-9 {
+		t.Errorf("gain: want the cached %.2f dB, got ×%.3f", db, g)
+	}
+
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Hour))
+	if _, ok := ln.cached(path); ok {
+		t.Error("a modified file should be measured again")
+	}
+}
+
+func TestNormalizeScalesTrack(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.wav")
+	writeWAV(t, path)
+	info, _ := os.Stat(path)
+
+	level := func(normalize bool) float64 {
+		svc := newTestOutput()
+		svc.SetNormalize(normalize)
+		svc.loudness.entries[path] = gainEntry{GainDB: -6.02, Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+		svc.PlayLooping(path)
+		waitStreams(t, svc, 1)
+		defer svc.Stop()
+		return rms(svc, 1000)
+	}
+	if ratio := level(true) / level(false); math.Abs(ratio-0.5) > 0.01 {
+		t.Errorf("want the track at half level, got ×%.3f", ratio)
+	}
+}
@@ -0,0 +1,52 @@
+package audio
+
+import (
+	"encoding/base64"
+	"net/http"
+	"os"
+	"strings"
+
+	"focusplay/internal/domain"
+
+	"github.com/dhowden/tag"
+)
+
+// maxCoverArt caps the embedded picture handed to the frontend; larger ones
+// are left out.
+const maxCoverArt = 1 << 20
+
+// readMeta reads the title, artist and album of an audio file, and its cover
+// art as a data: URL: ID3v2 or ID3v1 for MP3, Vorbis comments for FLAC and
+// Ogg. Files without tags, or with tags it cannot parse, give an empty
+// TrackMeta and no cover.
+func readMeta(path string) (meta domain.TrackMeta, cover string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return meta, ""
+	}
+	defer f.Close()
+	m, err := tag.ReadFrom(f)
+	if err != nil {
+		return meta, ""
+	}
+	meta = domain.TrackMeta{
+		Title:  strings.TrimSpace(m.Title()),
+		Artist: strings.TrimSpace(m.Artist()),
+		Album:  strings.TrimSpace(m.Album()),
+	}
+	if meta.Artist == "" {
+		meta.Artist = strings.TrimSpace(m.AlbumArtist())
+	}
+	if p := m.Picture(); p != nil && len(p.Data) > 0 && len(p.Data) <= maxCoverArt {
+		cover = dataURL(p.MIMEType, p.Data)
+	}
+	return meta, cover
+}
+
+// dataURL encodes a picture for an <img> src, sniffing the type when the tag omits it.
+func dataURL(mime string, data []byte) string {
+	if !strings.HasPrefix(mime, "image/") {
+		mime = http.DetectContentType(data)
+	}
+	return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
@@ -0,0 +1,126 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"focusplay/internal/domain"
+)
+
+// pngHeader is enough of a PNG for content sniffing.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// id3v23 builds an ID3v2.3 tag from frame IDs and bodies.
+func id3v23(frames ...[2]string) []byte {
+	var body bytes.Buffer
+	for _, f := range frames {
+		body.WriteString(f[0])
+		binary.Write(&body, binary.BigEndian, uint32(len(f[1])))
+		body.Write([]byte{0, 0})
+		body.WriteString(f[1])
+	}
+	n := body.Len()
+	head := []byte{'I', 'D', '3', 3, 0, 0, byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}
+	return append(head, body.Bytes()...)
+}
+
+func TestReadMetaID3v2(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "song.mp3")
+	tag := id3v23(
+		[2]string{"TIT2", "\x00Rain on Glass"},
+		[2]string{"TPE1", "\x00Quiet Rooms"},
+		[2]string{"TALB", "\x00Night Work"},
+		[2]string{"APIC", "\x00image/png\x00\x03\x00" + string(pngHeader)},
+	)
+	os.WriteFile(path, append(tag, 0xFF, 0xFB, 0x90, 0x00), 0o644)
+
+	meta, cover := readMeta(path)
+	if meta.Title != "Rain on Glass" || meta.Artist != "Quiet Rooms" || meta.Album != "Night Work" {
+		t.Errorf("unexpected tags %+v", meta)
+	}
+	if !strings.HasPrefix(cover, "data:image/png;base64,") {
+		t.Errorf("want a PNG data URL, got %.40q", cover)
+	}
+}
+
+// writeFLACTags writes a FLAC header holding only a Vorbis comment block.
+func writeFLACTags(t *testing.T, path string, fields ...string) {
+	t.Helper()
+	var comment bytes.Buffer
+	field := func(s string) {
+		binary.Write(&comment, binary.LittleEndian, uint32(len(s)))
+		comment.WriteString(s)
+	}
+	field("focusplay test")
+	binary.Write(&comment, binary.LittleEndian, uint32(len(fields)))
+	for _, f := range fields {
+		field(f)
+	}
+	n := comment.Len()
+	data := append([]byte("fLaC"), 0x80|4, byte(n>>16), byte(n>>8), byte(n))
+	if err := os.WriteFile(path, append(data, comment.Bytes()...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadMetaVorbisComment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tide.flac")
+	writeFLACTags(t, path, "TITLE=Low Tide", "ARTIST=Harbour", "ALBUM=Coastline")
+
+	meta, _ := readMeta(path)
+	if meta.Title != "Low Tide" || meta.Artist != "Harbour" || meta.Album != "Coastline" {
+		t.Errorf("unexpected tags %+v", meta)
+	}
+}
+
+func TestReadMetaWithoutTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plain.wav")
+	writeWAV(t, path)
+	if meta, cover := readMeta(path); meta != (domain.TrackMeta{}) || cover != "" {
+		t.Errorf("want empty metadata, got %+v %q", meta, cover)
+	}
+}
+
+func TestStateReportsDurationAndElapsed(t *testing.T) {
+	dir := t.TempDir()
+	writeWAV(t, filepath.Join(dir, "a.wav"))
+	svc := newTestOutput()
+	svc.PlayFolder(dir, false, domain.ScanOptions{})
+	deadline := time.Now().Add(2 * time.Second)
+	for svc.GetState().Meta == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("want track metadata in the state")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	pull(svc, outputRate.N(50*time.Millisecond))
+
+	st := svc.GetState()
+	if st.Meta.DurationMs != 100 {
+		t.Errorf("duration: want 100 ms, got %d", st.Meta.DurationMs)
+	}
+	if st.Meta.ElapsedMs < 45 || st.Meta.ElapsedMs > 55 {
+		t.Errorf("elapsed: want ~50 ms, got %d", st.Meta.ElapsedMs)
+	}
+
+	svc.PlayNoise(NoisePink)
+	if st := svc.GetState(); st.Meta != nil {
+		t.Errorf("generated audio has no track metadata, got %+v", st.Meta)
+	}
+}
+
+func TestCoverArtOnlyForThePlayingTrack(t *testing.T) {
+	svc := newTestOutput()
+	svc.list = &trackList{meta: &domain.TrackMeta{Path: "/music/a.mp3"}, cover: "data:image/png;base64,AAAA"}
+	if got := svc.CoverArt("/music/a.mp3"); got != svc.list.cover {
+		t.Errorf("playing track: got %q", got)
+	}
+	if got := svc.CoverArt("/music/b.mp3"); got != "" {
+		t.Errorf("a track no longer playing has no cover, got %q", got)
+	}
+}
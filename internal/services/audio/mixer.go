@@ -0,0 +1,119 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gopxl/beep"
+	"github.com/gopxl/beep/effects"
+)
+
+// Channel names accepted by SetChannelVolume.
+const (
+	ChannelMusic   = "music"   // background music (profile work/break audio)
+	ChannelAmbient = "ambient" // ambient layers playing alongside the music
+	ChannelCue     = "cue"     // UI cues and completion chimes
+)
+
+// outputRate is the fixed sample rate of the output device. Every source is
+// resampled to it, so switching tracks never reopens the output.
+const outputRate = beep.SampleRate(44100)
+
+// errNoOutput is returned when the output device cannot be opened.
+var errNoOutput = errors.New("audio output unavailable")
+
+// channel is a mixer bus with its own tone and volume: one per master channel,
+// plus one per soundscape layer nested inside the music channel.
+type channel struct {
+	mixer   beep.Mixer
+	filters filterChain // EQ and muffle, see Service.applyFiltersLocked
+	vol     effects.Volume
+	ctrl    beep.Ctrl // pauses the bus in the master mixer without losing stream positions
+}
+
+func newChannel(level float64) *channel {
+	c := &channel{}
+	c.filters = filterChain{Streamer: &c.mixer}
+	c.vol = effects.Volume{Streamer: &c.filters, Base: 2}
+	c.ctrl = beep.Ctrl{Streamer: &c.vol}
+	c.setLevel(level)
+	return c
+}
+
+// setLevel sets the bus volume (0.0–1.0). Call with the sink locked once playing.
+func (c *channel) setLevel(v float64) {
+	c.vol.Volume = linearToLog(v)
+	c.vol.Silent = v == 0
+}
+
+// newMixer builds the long-lived master mixer with one bus per channel.
+func newMixer(musicLevel float64) (*beep.Mixer, map[string]*channel) {
+	master := &beep.Mixer{}
+	channels := map[string]*channel{
+		ChannelMusic:   newChannel(musicLevel),
+		ChannelAmbient: newChannel(1),
+		ChannelCue:     newChannel(1),
+	}
+	for _, c := range channels {
+		master.Add(&c.ctrl)
+	}
+	return master, channels
+}
+
+// ensureOutput opens the sink once at outputRate and starts streaming the
+// master mixer. Retried on every play until it succeeds (e.g. device plugged in).
+func (s *Service) ensureOutput() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.outputOK {
+		return nil
+	}
+	if err := s.sink.Open(s.master, outputRate); err != nil {
+		return fmt.Errorf("%w: %v", errNoOutput, err)
+	}
+	s.outputOK = true
+	return nil
+}
+
+// addStream resamples st from sr to outputRate and mixes it into bus (a
+// channel or a soundscape layer). The returned Ctrl removes it again via removeStream.
+func (s *Service) addStream(bus *channel, st beep.Streamer, sr beep.SampleRate) *beep.Ctrl {
+	if sr != outputRate {
+		st = beep.Resample(4, sr, outputRate, st)
+	}
+	ctrl := &beep.Ctrl{Streamer: st}
+	s.sink.Lock()
+	bus.mixer.Add(ctrl)
+	s.sink.Unlock()
+	return ctrl
+}
+
+// removeStream drops a stream added by addStream; a nil Streamer drains the
+// Ctrl, so the channel mixer discards it on its next pull.
+func (s *Service) removeStream(ctrl *beep.Ctrl) {
+	s.sink.Lock()
+	ctrl.Streamer = nil
+	s.sink.Unlock()
+}
+
+// addFaded resamples st from sr to outputRate and mixes it into bus behind a
+// fader that ramps in over fadeIn. The stream leaves the mixer once it ends
+// or has been faded out with fadeOut.
+func (s *Service) addFaded(bus *channel, st beep.Streamer, sr beep.SampleRate, fadeIn time.Duration) *fader {
+	if sr != outputRate {
+		st = beep.Resample(4, sr, outputRate, st)
+	}
+	f := newFader(st, outputRate.N(fadeIn))
+	s.sink.Lock()
+	bus.mixer.Add(f)
+	s.sink.Unlock()
+	return f
+}
+
+// fadeOut fades a stream added by addFaded to silence over d, after which it ends.
+func (s *Service) fadeOut(f *fader, d time.Duration) {
+	s.sink.Lock()
+	f.fadeOut(outputRate.N(d))
+	s.sink.Unlock()
+}
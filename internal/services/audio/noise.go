@@ -0,0 +1,115 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/gopxl/beep"
+)
+
+// NoisePrefix marks a generated-noise source in a profile music path, e.g. "noise:pink".
+const NoisePrefix = "noise:"
+
+// Generated noise kinds.
+const (
+	NoiseWhite = "white" // flat spectrum
+	NoisePink  = "pink"  // −3 dB/octave
+	NoiseBrown = "brown" // −6 dB/octave
+	NoiseRain  = "rain"  // pink noise with the lows thinned out plus sparse droplets
+	NoiseOcean = "ocean" // brown noise swelling like slow waves
+)
+
+// NoiseKinds lists the generated noise kinds in display order.
+func NoiseKinds() []string {
+	return []string{NoiseWhite, NoisePink, NoiseBrown, NoiseRain, NoiseOcean}
+}
+
+// noise is an endless stereo noise generator. Each channel keeps its own
+// filter state and random stream so the two sides are decorrelated.
+type noise struct {
+	kind  string
+	sr    float64
+	rng   [2]*rand.Rand // one random stream per channel
+	pink  [2][7]float64 // Paul Kellet's pink filter taps
+	brown [2]float64    // leaky integrator state
+	hp    [2]float64    // previous input for the rain high-pass
+	drop  [2]float64    // decaying droplet envelope
+	pos   int
+}
+
+// newNoise returns a generator for kind at sample rate sr, seeded for repeatability.
+func newNoise(kind string, sr beep.SampleRate, seed int64) (beep.Streamer, error) {
+	switch kind {
+	case NoiseWhite, NoisePink, NoiseBrown, NoiseRain, NoiseOcean:
+	default:
+		return nil, fmt.Errorf("unknown noise %q", kind)
+	}
+	return &noise{kind: kind, sr: float64(sr), rng: [2]*rand.Rand{
+		rand.New(rand.NewSource(seed)),
+		rand.New(rand.NewSource(seed + 1)),
+	}}, nil
+}
+
+func (n *noise) Stream(samples [][2]float64) (int, bool) {
+	for i := range samples {
+		for c := 0; c < 2; c++ {
+			samples[i][c] = n.next(c)
+		}
+		n.pos++
+	}
+	return len(samples), true
+}
+
+func (n *noise) Err() error { return nil }
+
+// next produces one sample for channel c.
+func (n *noise) next(c int) float64 {
+	white := n.rng[c].Float64()*2 - 1
+	switch n.kind {
+	case NoiseWhite:
+		return 0.25 * white
+	case NoisePink:
+		return 0.25 * n.pinkSample(c, white)
+	case NoiseBrown:
+		return 0.25 * n.brownSample(c, white)
+	case NoiseRain:
+		// First-difference high-pass tilts pink noise towards hiss; droplets
+		// are short decaying bursts triggered at random.
+		p := n.pinkSample(c, white)
+		hiss := p - 0.5*n.hp[c]
+		n.hp[c] = p
+		if n.rng[c].Float64() < 6/n.sr {
+			n.drop[c] = 1
+		}
+		n.drop[c] *= 0.997
+		return 0.18*hiss + 0.15*n.drop[c]*white
+	case NoiseOcean:
+		// Two slow LFOs give irregular ~8–13 s swells.
+		t := float64(n.pos) / n.sr
+		swell := 0.55 + 0.3*math.Sin(2*math.Pi*t/8) + 0.15*math.Sin(2*math.Pi*t/13+1)
+		return 0.3 * swell * n.brownSample(c, white)
+	}
+	return 0
+}
+
+// pinkSample filters white noise to −3 dB/octave (Paul Kellet's refined method).
+func (n *noise) pinkSample(c int, white float64) float64 {
+	b := &n.pink[c]
+	b[0] = 0.99886*b[0] + white*0.0555179
+	b[1] = 0.99332*b[1] + white*0.0750759
+	b[2] = 0.96900*b[2] + white*0.1538520
+	b[3] = 0.86650*b[3] + white*0.3104856
+	b[4] = 0.55000*b[4] + white*0.5329522
+	b[5] = -0.7616*b[5] - white*0.0168980
+	out := b[0] + b[1] + b[2] + b[3] + b[4] + b[5] + b[6] + white*0.5362
+	b[6] = white * 0.115926
+	return out * 0.11
+}
+
+// brownSample integrates white noise (−6 dB/octave) with a small leak so it
+// never drifts away from zero.
+func (n *noise) brownSample(c int, white float64) float64 {
+	n.brown[c] = (n.brown[c] + 0.02*white) / 1.02
+	return n.brown[c] * 3.5
+}
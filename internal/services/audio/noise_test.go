@@ -0,0 +1,128 @@
+package audio
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+	"time"
+)
+
+// fft is an in-place iterative radix-2 FFT; len(x) must be a power of two.
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		w := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			wk := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := x[start+k], x[start+k+size/2]*wk
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				wk *= w
+			}
+		}
+	}
+}
+
+// spectrum returns the Hann-windowed power spectrum of the left channel,
+// averaged over blocks of 4096 samples.
+func spectrum(t *testing.T, kind string) []float64 {
+	t.Helper()
+	gen, err := newNoise(kind, outputRate, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const size, blocks = 4096, 48
+	power := make([]float64, size/2)
+	buf := make([][2]float64, size)
+	x := make([]complex128, size)
+	for b := 0; b < blocks; b++ {
+		gen.Stream(buf)
+		for i := range buf {
+			w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(size-1))
+			x[i] = complex(buf[i][0]*w, 0)
+		}
+		fft(x)
+		for i := range power {
+			power[i] += real(x[i])*real(x[i]) + imag(x[i])*imag(x[i])
+		}
+	}
+	return power
+}
+
+// bandDB returns the mean power in [lo, hi) Hz in decibels.
+func bandDB(power []float64, lo, hi float64) float64 {
+	binHz := float64(outputRate) / float64(2*len(power))
+	sum, n := 0.0, 0
+	for i := int(lo / binHz); i < int(hi/binHz); i++ {
+		sum += power[i]
+		n++
+	}
+	return 10 * math.Log10(sum/float64(n))
+}
+
+// slope measures the spectral tilt in dB per octave between 250–500 Hz and 4–8 kHz.
+func slope(power []float64) float64 {
+	return (bandDB(power, 4000, 8000) - bandDB(power, 250, 500)) / 4
+}
+
+func TestNoiseSpectralShape(t *testing.T) {
+	tests := []struct {
+		kind string
+		want float64 // dB per octave
+	}{
+		{NoiseWhite, 0},
+		{NoisePink, -3},
+		{NoiseBrown, -6},
+	}
+	for _, tc := range tests {
+		got := slope(spectrum(t, tc.kind))
+		if math.Abs(got-tc.want) > 1 {
+			t.Errorf("%s noise: want %.0f dB/octave, got %.2f", tc.kind, tc.want, got)
+		}
+	}
+}
+
+func TestRainBrighterThanPinkOceanDarkerThanPink(t *testing.T) {
+	pink := slope(spectrum(t, NoisePink))
+	if rain := slope(spectrum(t, NoiseRain)); rain <= pink {
+		t.Errorf("rain should tilt brighter than pink: rain %.2f, pink %.2f dB/oct", rain, pink)
+	}
+	if ocean := slope(spectrum(t, NoiseOcean)); ocean >= pink {
+		t.Errorf("ocean should tilt darker than pink: ocean %.2f, pink %.2f dB/oct", ocean, pink)
+	}
+}
+
+func TestNoiseStaysInRange(t *testing.T) {
+	buf := make([][2]float64, outputRate.N(5*time.Second))
+	for _, kind := range NoiseKinds() {
+		gen, _ := newNoise(kind, outputRate, 7)
+		gen.Stream(buf)
+		peak, corr := 0.0, 0.0
+		for _, s := range buf {
+			peak = math.Max(peak, math.Max(math.Abs(s[0]), math.Abs(s[1])))
+			corr += s[0] * s[1]
+		}
+		if peak == 0 || peak > 1 {
+			t.Errorf("%s noise: peak %.3f outside (0, 1]", kind, peak)
+		}
+		if kind == NoiseWhite && math.Abs(corr)/float64(len(buf)) > 1e-3 {
+			t.Errorf("white noise channels should be decorrelated, got %.5f", corr/float64(len(buf)))
+		}
+	}
+}
+
+func TestNewNoiseUnknownKind(t *testing.T) {
+	if _, err := newNoise("purple", outputRate, 1); err == nil {
+		t.Error("expected error for unknown noise kind")
+	}
+}
@@ -0,0 +1,164 @@
+package audio
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"focusplay/internal/domain"
+)
+
+// trackList is a file, folder or playlist being played by playTracks. The
+// info and the fields after from change as it plays; guard them with Service.mu.
+type trackList struct {
+	source  string
+	shuffle bool
+	info    string
+	from    time.Duration // where the first track starts, for a restored list
+
+	tracks []string
+	idx    int
+	track  *fader            // stream of tracks[idx], nil until it starts
+	meta   *domain.TrackMeta // tags of tracks[idx]
+	cover  string            // cover art of tracks[idx], see CoverArt
+	errors []domain.TrackError
+}
+
+// newTrackList builds the list for source without banned or known bad
+// tracks, shuffling it when asked.
+func (s *Service) newTrackList(source string, tracks []string, shuffle bool) *trackList {
+	tracks = s.bad.playable(s.shuffle.playable(tracks))
+	if shuffle {
+		tracks = s.shuffle.order(tracks)
+	}
+	return &trackList{source: source, shuffle: shuffle, info: trackInfo(source, len(tracks), shuffle), tracks: tracks}
+}
+
+// trackInfo is the status line shown under the track name.
+func trackInfo(source string, n int, shuffle bool) string {
+	var kind string
+	switch {
+	case isPlaylist(source):
+		kind = "playlist"
+	case isDir(source):
+		kind = "folder"
+	default:
+		return "Looping"
+	}
+	if shuffle {
+		return fmt.Sprintf("Shuffle %s · %d tracks", kind, n)
+	}
+	return fmt.Sprintf("%s%s · %d tracks", strings.ToUpper(kind[:1]), kind[1:], n)
+}
+
+// Playback reports the playing track list and the position in its current
+// track, or nil when the music is generated, a soundscape or stopped.
+func (s *Service) Playback() *domain.PlaybackState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.list
+	if l == nil {
+		return nil
+	}
+	pb := &domain.PlaybackState{
+		Source:  l.source,
+		Shuffle: l.shuffle,
+		Tracks:  append([]string(nil), l.tracks...),
+		Index:   l.idx,
+	}
+	pb.PositionMs = l.position(s.sink).Milliseconds()
+	return pb
+}
+
+// position is how far into its file the current track is. Call with Service.mu held.
+func (l *trackList) position(sink Sink) time.Duration {
+	if l.track == nil {
+		return 0
+	}
+	sink.Lock()
+	defer sink.Unlock()
+	if l.track.closed {
+		return 0
+	}
+	return l.track.start + outputRate.D(l.track.pos)
+}
+
+// ResumeSource plays source like PlaySource, continuing from pb when it was
+// saved for the same source: same order, same track, same position. Tracks
+// that have gone are dropped and new ones are added at the end; if the saved
+// track itself has gone, playback picks up at the next one that is left.
+func (s *Service) ResumeSource(source string, shuffle bool, opts domain.ScanOptions, pb *domain.PlaybackState) {
+	if pb == nil || pb.Source != source || pb.Shuffle != shuffle || len(pb.Tracks) == 0 || isGenerated(source) || isStream(source) {
+		s.PlaySource(source, shuffle, opts)
+		return
+	}
+
+	var fresh []string
+	switch {
+	case isPlaylist(source):
+		fresh, _, _ = parsePlaylist(source)
+	case isDir(source):
+		fresh, _ = s.index.tracks(source, opts)
+	default:
+		if _, err := os.Stat(source); err == nil {
+			fresh = []string{source}
+		}
+	}
+	var order func([]string) []string
+	if shuffle {
+		order = s.shuffle.order
+	}
+	tracks, idx, same := restoreOrder(pb.Tracks, pb.Index, s.bad.playable(s.shuffle.playable(fresh)), order)
+	if len(tracks) == 0 {
+		s.PlaySource(source, shuffle, opts) // reports what is wrong with the source
+		return
+	}
+
+	l := &trackList{source: source, shuffle: shuffle, info: trackInfo(source, len(tracks), shuffle), tracks: tracks, idx: idx}
+	if same {
+		l.from = time.Duration(pb.PositionMs) * time.Millisecond
+	}
+	s.playTracks(l)
+}
+
+// restoreOrder rebuilds a saved play order against the tracks on disk now
+// (fresh). A shuffled order (shuffle is non-nil) keeps its sequence, without
+// the tracks that have gone, and new tracks are shuffled onto the end; an
+// unshuffled one simply takes the fresh order. It returns the order, where to
+// continue in it and whether that is still the saved track.
+func restoreOrder(saved []string, current int, fresh []string, shuffle func([]string) []string) ([]string, int, bool) {
+	onDisk := make(map[string]bool, len(fresh))
+	for _, t := range fresh {
+		onDisk[t] = true
+	}
+	order := append([]string(nil), fresh...)
+	if shuffle != nil {
+		order = order[:0]
+		known := make(map[string]bool, len(saved))
+		for _, t := range saved {
+			known[t] = true
+			if onDisk[t] {
+				order = append(order, t)
+			}
+		}
+		var added []string
+		for _, t := range fresh {
+			if !known[t] {
+				added = append(added, t)
+			}
+		}
+		order = append(order, shuffle(added)...)
+	}
+
+	at := make(map[string]int, len(order))
+	for i, t := range order {
+		at[t] = i
+	}
+	for i := max(current, 0); i < len(saved); i++ {
+		if j, ok := at[saved[i]]; ok {
+			return order, j, i == current
+		}
+	}
+	return order, 0, false
+}
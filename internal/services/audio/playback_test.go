@@ -0,0 +1,107 @@
+package audio
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"focusplay/internal/domain"
+)
+
+// reverse stands in for the shuffle so new tracks land in a known order.
+func reverse(tracks []string) []string {
+	out := make([]string, len(tracks))
+	for i, t := range tracks {
+		out[len(tracks)-1-i] = t
+	}
+	return out
+}
+
+func TestRestoreOrderKeepsShuffle(t *testing.T) {
+	saved := []string{"c", "a", "d", "b"}
+	order, idx, same := restoreOrder(saved, 2, []string{"a", "b", "c", "d", "e", "f"}, reverse)
+	if !reflect.DeepEqual(order, []string{"c", "a", "d", "b", "f", "e"}) || idx != 2 || !same {
+		t.Errorf("got %v at %d (same %v)", order, idx, same)
+	}
+
+	// The current track has gone: continue with the next one that is left.
+	order, idx, same = restoreOrder(saved, 2, []string{"a", "b", "c"}, reverse)
+	if !reflect.DeepEqual(order, []string{"c", "a", "b"}) || idx != 2 || same {
+		t.Errorf("got %v at %d (same %v)", order, idx, same)
+	}
+
+	if order, _, _ := restoreOrder(saved, 0, nil, reverse); len(order) != 0 {
+		t.Errorf("nothing left on disk: got %v", order)
+	}
+}
+
+func TestRestoreOrderUnshuffledFollowsDisk(t *testing.T) {
+	order, idx, same := restoreOrder([]string{"a", "b", "c"}, 1, []string{"0", "a", "b", "c"}, nil)
+	if !reflect.DeepEqual(order, []string{"0", "a", "b", "c"}) || idx != 2 || !same {
+		t.Errorf("got %v at %d (same %v)", order, idx, same)
+	}
+}
+
+func TestResumeSourceContinuesTrackAndPosition(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.wav", "b.wav", "c.wav", "d.wav"} {
+		writeWAV(t, filepath.Join(dir, name))
+	}
+	svc := newTestOutput()
+	svc.PlayFolder(dir, true, domain.ScanOptions{})
+	waitStreams(t, svc, 1)
+	pull(svc, 2000)
+	saved := svc.Playback()
+	if saved == nil || saved.PositionMs < 40 || saved.PositionMs > 50 {
+		t.Fatalf("want ~45 ms into the first track, got %+v", saved)
+	}
+	svc.Stop()
+
+	resumed := newTestOutput()
+	resumed.ResumeSource(dir, true, domain.ScanOptions{}, saved)
+	waitStreams(t, resumed, 1)
+	got := resumed.Playback()
+	if !reflect.DeepEqual(got.Tracks, saved.Tracks) || got.Index != saved.Index {
+		t.Fatalf("want the saved order and track, got %+v", got)
+	}
+	if got.PositionMs != saved.PositionMs {
+		t.Errorf("want to continue at %d ms, got %d ms", saved.PositionMs, got.PositionMs)
+	}
+	if name := resumed.GetState().TrackName; name != filepath.Base(saved.Tracks[saved.Index]) {
+		t.Errorf("want the saved track playing, got %q", name)
+	}
+}
+
+func TestResumeSourceAfterTrackRemoved(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.wav", "b.wav", "c.wav"} {
+		writeWAV(t, filepath.Join(dir, name))
+	}
+	saved := &domain.PlaybackState{
+		Source:     dir,
+		Tracks:     []string{filepath.Join(dir, "a.wav"), filepath.Join(dir, "b.wav"), filepath.Join(dir, "c.wav")},
+		Index:      1,
+		PositionMs: 50,
+	}
+	os.Remove(filepath.Join(dir, "b.wav"))
+
+	svc := newTestOutput()
+	svc.ResumeSource(dir, false, domain.ScanOptions{}, saved)
+	waitStreams(t, svc, 1)
+	got := svc.Playback()
+	if got.Tracks[got.Index] != filepath.Join(dir, "c.wav") || got.PositionMs != 0 {
+		t.Errorf("want c.wav from the start, got %+v", got)
+	}
+}
+
+func TestResumeSourceIgnoresOtherSource(t *testing.T) {
+	dir := t.TempDir()
+	writeWAV(t, filepath.Join(dir, "a.wav"))
+	svc := newTestOutput()
+	svc.ResumeSource(dir, false, domain.ScanOptions{}, &domain.PlaybackState{Source: "/elsewhere", Tracks: []string{"/elsewhere/x.mp3"}, PositionMs: 9000})
+	waitStreams(t, svc, 1)
+	if got := svc.Playback(); got.Source != dir || got.PositionMs != 0 {
+		t.Errorf("want a fresh start of %s, got %+v", dir, got)
+	}
+}
@@ -0,0 +1,143 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// PlaylistExtensions lists the playlist formats a music path may point at.
+func PlaylistExtensions() []string {
+	return []string{".m3u", ".m3u8", ".pls"}
+}
+
+// isPlaylist reports whether path names a playlist file.
+func isPlaylist(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range PlaylistExtensions() {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePlaylist reads an M3U/M3U8 or PLS playlist. Relative entries are
+// resolved against the playlist's folder. Entries that do not exist on disk
+// are returned in missing rather than tracks; other entries (e.g. URLs) are ignored.
+func parsePlaylist(path string) (tracks, missing []string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	if !utf8.Valid(data) {
+		data = latin1ToUTF8(data) // plain .m3u files are traditionally Latin-1
+	}
+
+	var entries []string
+	if strings.ToLower(filepath.Ext(path)) == ".pls" {
+		entries, err = parsePLS(data)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		entries = parseM3U(data)
+	}
+
+	base := filepath.Dir(path)
+	for _, e := range entries {
+		p, ok := resolveEntry(base, e)
+		if !ok {
+			continue
+		}
+		if info, err := os.Stat(p); err != nil || info.IsDir() {
+			missing = append(missing, p)
+			continue
+		}
+		tracks = append(tracks, p)
+	}
+	return tracks, missing, nil
+}
+
+// parseM3U returns the entries of an M3U playlist: every non-blank line that
+// is not a #directive.
+func parseM3U(data []byte) []string {
+	var entries []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			entries = append(entries, line)
+		}
+	}
+	return entries
+}
+
+// parsePLS returns the FileN entries of a PLS playlist in N order.
+func parsePLS(data []byte) ([]string, error) {
+	files := map[int]string{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(sc.Text()), "=")
+		if !ok || len(key) <= 4 || !strings.EqualFold(key[:4], "file") {
+			continue
+		}
+		if n, err := strconv.Atoi(key[4:]); err == nil {
+			files[n] = strings.TrimSpace(value)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no FileN entries in playlist")
+	}
+	nums := make([]int, 0, len(files))
+	for n := range files {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+	entries := make([]string, len(nums))
+	for i, n := range nums {
+		entries[i] = files[n]
+	}
+	return entries, nil
+}
+
+// resolveEntry turns a playlist entry into a local path. file:// URLs are
+// unwrapped; other URLs are not local files and report false.
+func resolveEntry(base, entry string) (string, bool) {
+	if u, err := url.Parse(entry); err == nil && len(u.Scheme) > 1 {
+		if u.Scheme != "file" {
+			return "", false
+		}
+		entry = u.Path
+		if len(entry) > 2 && entry[0] == '/' && entry[2] == ':' {
+			entry = entry[1:] // file:///C:/Music/a.mp3
+		}
+	}
+	// Playlists written on Windows use backslashes.
+	entry = filepath.FromSlash(strings.ReplaceAll(entry, `\`, "/"))
+	if !filepath.IsAbs(entry) && !isWindowsAbs(entry) {
+		entry = filepath.Join(base, entry)
+	}
+	return entry, true
+}
+
+// isWindowsAbs recognises drive-letter paths such as C:/Music even on other systems.
+func isWindowsAbs(p string) bool {
+	return len(p) >= 3 && p[1] == ':' && (p[2] == '/' || p[2] == '\\')
+}
+
+func latin1ToUTF8(b []byte) []byte {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return []byte(string(runes))
+}
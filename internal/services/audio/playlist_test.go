@@ -0,0 +1,119 @@
+package audio
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// touch creates empty files under dir.
+func touch(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, n := range names {
+		p := filepath.Join(dir, n)
+		os.MkdirAll(filepath.Dir(p), 0o755)
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestParseM3UResolvesRelativePaths(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir, "lofi/a.mp3", "b.flac", "elsewhere/c.ogg")
+	abs := filepath.Join(dir, "elsewhere", "c.ogg")
+	list := "\xEF\xBB\xBF#EXTM3U\n#EXTINF:123,Artist - A\nlofi/a.mp3\n\n" +
+		`lofi\gone.mp3` + "\nhttp://radio.example/stream\n" + abs + "\r\nfile://" + filepath.ToSlash(filepath.Join(dir, "b.flac")) + "\n"
+	path := filepath.Join(dir, "focus.m3u8")
+	os.WriteFile(path, []byte(list), 0o644)
+
+	tracks, missing, err := parsePlaylist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "lofi", "a.mp3"), abs, filepath.Join(dir, "b.flac")}
+	if !reflect.DeepEqual(tracks, want) {
+		t.Errorf("tracks:\n got %v\nwant %v", tracks, want)
+	}
+	if len(missing) != 1 || missing[0] != filepath.Join(dir, "lofi", "gone.mp3") {
+		t.Errorf("want the missing entry reported, got %v", missing)
+	}
+}
+
+func TestParseM3ULatin1(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir, "café.mp3")
+	path := filepath.Join(dir, "old.m3u")
+	os.WriteFile(path, []byte("caf\xE9.mp3\n"), 0o644) // Latin-1 é
+
+	tracks, missing, err := parsePlaylist(path)
+	if err != nil || len(tracks) != 1 || len(missing) != 0 {
+		t.Errorf("Latin-1 entry not resolved: tracks %v, missing %v, err %v", tracks, missing, err)
+	}
+}
+
+func TestParsePLSOrdersByNumber(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir, "one.mp3", "two.mp3", "three.mp3")
+	pls := "[playlist]\nNumberOfEntries=3\nFile2=two.mp3\nTitle2=Two\nFile10=three.mp3\nfile1=one.mp3\nVersion=2\n"
+	path := filepath.Join(dir, "list.pls")
+	os.WriteFile(path, []byte(pls), 0o644)
+
+	tracks, _, err := parsePlaylist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tr := range tracks {
+		names = append(names, filepath.Base(tr))
+	}
+	if want := []string{"one.mp3", "two.mp3", "three.mp3"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("want %v, got %v", want, names)
+	}
+}
+
+func TestParsePLSWithoutEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.pls")
+	os.WriteFile(path, []byte("[playlist]\nNumberOfEntries=0\n"), 0o644)
+	if _, _, err := parsePlaylist(path); err == nil {
+		t.Error("expected an error for a PLS without entries")
+	}
+}
+
+type eventLog struct {
+	mu     sync.Mutex
+	events map[string][]any
+}
+
+func (l *eventLog) Emit(event string, data any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.events == nil {
+		l.events = map[string][]any{}
+	}
+	l.events[event] = append(l.events[event], data)
+}
+
+func TestPlayPlaylistWarnsAboutMissingEntries(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gone.m3u")
+	os.WriteFile(path, []byte("a.mp3\nb.mp3\n"), 0o644)
+
+	svc := newTestOutput()
+	log := &eventLog{}
+	svc.SetEmitter(log)
+	svc.PlayPlaylist(path, false)
+
+	if len(log.events["audioWarning"]) != 1 {
+		t.Fatalf("want one audioWarning, got %v", log.events["audioWarning"])
+	}
+	warning := log.events["audioWarning"][0].(map[string]interface{})
+	if paths := warning["paths"].([]string); len(paths) != 2 {
+		t.Errorf("want both missing paths reported, got %v", paths)
+	}
+	if st := svc.GetState(); st.TrackInfo != "No playable tracks in playlist" {
+		t.Errorf("want playback refused, got %+v", st)
+	}
+}
@@ -0,0 +1,186 @@
+package audio
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"focusplay/internal/domain"
+	"focusplay/internal/infra/storage"
+)
+
+// DefaultScanDepth is how many subfolder levels a music folder is searched
+// when the profile does not say.
+const DefaultScanDepth = 8
+
+// scanTracks walks root for audio files of every supported format, descending
+// up to opts.MaxDepth levels and following symlinked folders once each, so a
+// link back up the tree cannot loop. It also returns the modification time of
+// every folder it read, which is what the folder index uses to spot changes.
+func scanTracks(root string, opts domain.ScanOptions) ([]string, map[string]int64, error) {
+	depth := opts.MaxDepth
+	if depth == 0 {
+		depth = DefaultScanDepth
+	}
+	var tracks []string
+	dirs := map[string]int64{}
+	seen := map[string]bool{}
+
+	var walk func(dir, rel string, level int) error
+	walk = func(dir, rel string, level int) error {
+		real, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			return err
+		}
+		if seen[real] {
+			return nil // symlink loop, or a folder linked in twice
+		}
+		seen[real] = true
+		info, err := os.Stat(dir)
+		if err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		dirs[dir] = info.ModTime().UnixNano()
+
+		for _, e := range entries {
+			full := filepath.Join(dir, e.Name())
+			relPath := path.Join(rel, e.Name())
+			isDir := e.IsDir()
+			if e.Type()&fs.ModeSymlink != 0 {
+				target, err := os.Stat(full)
+				if err != nil {
+					continue // dangling link
+				}
+				isDir = target.IsDir()
+			}
+			if matchAny(opts.Exclude, relPath) {
+				continue
+			}
+			if isDir {
+				if level < depth {
+					_ = walk(full, relPath, level+1) // an unreadable subfolder only loses its own tracks
+				}
+				continue
+			}
+			if isSupported(e.Name()) && (len(opts.Include) == 0 || matchAny(opts.Include, relPath)) {
+				tracks = append(tracks, full)
+			}
+		}
+		return nil
+	}
+	return tracks, dirs, walk(root, "", 0)
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if matchGlob(p, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches a slash-separated path relative to the music folder,
+// ignoring case. Patterns without a "/" match the last element only.
+func matchGlob(pattern, rel string) bool {
+	pattern = strings.ToLower(filepath.ToSlash(strings.TrimSpace(pattern)))
+	rel = strings.ToLower(rel)
+	if pattern == "" {
+		return false
+	}
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+// matchSegments matches path elements one by one; "**" matches zero or more of them.
+func matchSegments(pat, segs []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(segs); i++ {
+				if matchSegments(pat[1:], segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], segs[0]); !ok {
+			return false
+		}
+		pat, segs = pat[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
+
+// folderIndex caches folder scans in folder-index.json so large libraries are
+// not walked on every start. An entry stays valid while every folder it read
+// keeps its modification time; adding, removing or renaming a file changes it.
+type folderIndex struct {
+	mu      sync.Mutex
+	path    string // "" keeps the index in memory only
+	entries map[string]indexEntry
+}
+
+type indexEntry struct {
+	Tracks []string         `json:"tracks"`
+	Dirs   map[string]int64 `json:"dirs"` // folder → modification time (Unix ns)
+}
+
+func newFolderIndex(filePath string) *folderIndex {
+	ix := &folderIndex{path: filePath}
+	if filePath != "" {
+		_ = storage.Load(filePath, &ix.entries)
+	}
+	if ix.entries == nil {
+		ix.entries = map[string]indexEntry{}
+	}
+	return ix
+}
+
+// tracks returns the tracks under root, from the index when nothing changed.
+func (ix *folderIndex) tracks(root string, opts domain.ScanOptions) ([]string, error) {
+	key := fmt.Sprintf("%s|%d|%s|%s", root, opts.MaxDepth,
+		strings.Join(opts.Include, ","), strings.Join(opts.Exclude, ","))
+
+	ix.mu.Lock()
+	e, ok := ix.entries[key]
+	ix.mu.Unlock()
+	if ok && e.fresh() {
+		return append([]string(nil), e.Tracks...), nil
+	}
+
+	tracks, dirs, err := scanTracks(root, opts)
+	if err != nil {
+		return nil, err
+	}
+	ix.mu.Lock()
+	ix.entries[key] = indexEntry{Tracks: tracks, Dirs: dirs}
+	if ix.path != "" {
+		_ = storage.Save(ix.path, ix.entries)
+	}
+	ix.mu.Unlock()
+	return append([]string(nil), tracks...), nil
+}
+
+func (e indexEntry) fresh() bool {
+	for dir, mtime := range e.Dirs {
+		info, err := os.Stat(dir)
+		if err != nil || info.ModTime().UnixNano() != mtime {
+			return false
+		}
+	}
+	return len(e.Dirs) > 0
+}
@@ -0,0 +1,152 @@
+package audio
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"focusplay/internal/domain"
+)
+
+// relNames returns tracks relative to root, slash-separated and sorted.
+func relNames(t *testing.T, root string, tracks []string) []string {
+	t.Helper()
+	var out []string
+	for _, tr := range tracks {
+		rel, err := filepath.Rel(root, tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, filepath.ToSlash(rel))
+	}
+	sort.Strings(out)
+	return out
+}
+
+func library(t *testing.T) string {
+	dir := t.TempDir()
+	touch(t, dir,
+		"intro.mp3",
+		"Artist/Album One/01.flac",
+		"Artist/Album One/02.flac",
+		"Artist/Live/01.mp3",
+		"Artist/Album One/Bonus/deep.ogg",
+		"Demos/rough.wav",
+		"Artist/cover.jpg",
+	)
+	return dir
+}
+
+func TestScanTracksRecurses(t *testing.T) {
+	root := library(t)
+	tracks, _, err := scanTracks(root, domain.ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"Artist/Album One/01.flac", "Artist/Album One/02.flac", "Artist/Album One/Bonus/deep.ogg",
+		"Artist/Live/01.mp3", "Demos/rough.wav", "intro.mp3",
+	}
+	if got := relNames(t, root, tracks); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+}
+
+func TestScanTracksDepthLimit(t *testing.T) {
+	root := library(t)
+	top, _, _ := scanTracks(root, domain.ScanOptions{MaxDepth: -1})
+	if got := relNames(t, root, top); !reflect.DeepEqual(got, []string{"intro.mp3"}) {
+		t.Errorf("top level only: got %v", got)
+	}
+	two, _, _ := scanTracks(root, domain.ScanOptions{MaxDepth: 2})
+	for _, name := range relNames(t, root, two) {
+		if name == "Artist/Album One/Bonus/deep.ogg" {
+			t.Error("depth 2 must not reach a third-level folder")
+		}
+	}
+}
+
+func TestScanTracksIncludeExclude(t *testing.T) {
+	root := library(t)
+	tracks, _, _ := scanTracks(root, domain.ScanOptions{
+		Include: []string{"*.flac", "*.OGG", "demos/**"},
+		Exclude: []string{"bonus", "**/02.*"},
+	})
+	want := []string{"Artist/Album One/01.flac", "Demos/rough.wav"}
+	if got := relNames(t, root, tracks); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern, rel string
+		want         bool
+	}{
+		{"*.mp3", "a/b/c.mp3", true},
+		{"live", "Artist/Live", true},
+		{"artist/*/01.*", "Artist/Live/01.mp3", true},
+		{"artist/*/01.*", "Artist/Album One/Bonus/01.mp3", false},
+		{"**/bonus/**", "Artist/Album One/Bonus/deep.ogg", true},
+		{"**/*.flac", "x.flac", true},
+		{"  ", "x.flac", false},
+	}
+	for _, c := range cases {
+		if got := matchGlob(c.pattern, c.rel); got != c.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", c.pattern, c.rel, got, c.want)
+		}
+	}
+}
+
+func TestScanTracksSurvivesSymlinkLoop(t *testing.T) {
+	root := library(t)
+	if err := os.Symlink(root, filepath.Join(root, "Artist", "loop")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	os.Symlink(filepath.Join(root, "Demos"), filepath.Join(root, "demos-link"))
+
+	done := make(chan []string, 1)
+	go func() {
+		tracks, _, _ := scanTracks(root, domain.ScanOptions{MaxDepth: 50})
+		done <- tracks
+	}()
+	select {
+	case tracks := <-done:
+		if len(tracks) != 6 {
+			t.Errorf("each real folder should be scanned once, got %d tracks: %v", len(tracks), relNames(t, root, tracks))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("scan did not finish; symlink loop?")
+	}
+}
+
+func TestFolderIndexReusesUnchangedScan(t *testing.T) {
+	root := library(t)
+	indexPath := filepath.Join(t.TempDir(), "folder-index.json")
+	ix := newFolderIndex(indexPath)
+	first, err := ix.tracks(root, domain.ScanOptions{})
+	if err != nil || len(first) != 6 {
+		t.Fatalf("first scan: %d tracks, err %v", len(first), err)
+	}
+
+	// Add a file but restore the folder's mtime: a rescan would find it, the index must not.
+	demos := filepath.Join(root, "Demos")
+	info, _ := os.Stat(demos)
+	touch(t, root, "Demos/sneaky.mp3")
+	os.Chtimes(demos, info.ModTime(), info.ModTime())
+
+	reloaded := newFolderIndex(indexPath)
+	if cached, _ := reloaded.tracks(root, domain.ScanOptions{}); len(cached) != 6 {
+		t.Errorf("unchanged folders should be served from the saved index, got %d tracks", len(cached))
+	}
+
+	// A real change bumps the folder's mtime and forces a rescan.
+	later := info.ModTime().Add(time.Minute)
+	os.Chtimes(demos, later, later)
+	if fresh, _ := reloaded.tracks(root, domain.ScanOptions{}); len(fresh) != 7 {
+		t.Errorf("changed folder should be rescanned, got %d tracks", len(fresh))
+	}
+}
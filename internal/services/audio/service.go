@@ -1,9 +1,9 @@
 package audio
 
 import (
+	"errors"
 	"fmt"
 	"math"
-	"math/rand"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,28 +15,56 @@ import (
 
 	"github.com/gopxl/beep"
 	"github.com/gopxl/beep/effects"
-	"github.com/gopxl/beep/mp3"
-	"github.com/gopxl/beep/speaker"
 )
 
-// Service handles MP3 playback (single file loop or shuffle folder).
+// Service handles music playback (single file loop or shuffle folder) for
+// every format in the decoder registry (see SupportedExtensions).
+// Everything plays through one long-lived master mixer: background music,
+// ambient layers and UI cues each have their own channel and volume, so they
+// overlap freely and changing tracks never touches the output device.
 // Silent-fails on missing / invalid files — never crashes the app.
 type Service struct {
-	mu      sync.Mutex
-	emitter events.Emitter
-	stopCh  chan struct{}
-	vol     float64         // 0.0 – 1.0
-	volCtrl *effects.Volume // currently-active volume control (nil when idle)
-	state   domain.AudioStatePayload
+	mu          sync.Mutex
+	emitter     events.Emitter
+	music       *run       // current music playback, nil when stopped
+	ambient     *run       // current ambient loop, nil when stopped
+	layers      []*channel // per-layer buses of the playing soundscape
+	scapeLayers []domain.SoundLayer
+	list        *trackList    // the playing file, folder or playlist; see Playback
+	fadeIn      time.Duration // fade-in when music starts from silence
+	crossfade   time.Duration // overlap when one source or track replaces another
+	vol         float64       // master music level set by SetVolume, 0.0 – 1.0
+	phaseVol    float64       // level of the current work or break phase, scaled by vol
+	eq          domain.EQ     // tone of the current work or break phase
+	muffled     bool          // music and ambient low-passed for a call, see SetMuffle
+	state       domain.AudioStatePayload
+	master      *beep.Mixer
+	channels    map[string]*channel
+	sink        Sink
+	outputOK    bool // sink opened
+	paused      bool // music channel held by PauseAudio
+	index       *folderIndex
+	loudness    *loudness
+	shuffle     *shuffler
+	bad         *badFiles
 }
 
-// New creates a Service. Call SetEmitter after the Wails context is available.
-func New() *Service {
-	return &Service{
-		vol:     0.7,
-		emitter: events.Noop{},
-		state:   domain.AudioStatePayload{State: domain.AudioIdle},
+// New creates a Service that keeps its caches under dataDir ("" keeps them in
+// memory). Call SetEmitter after the Wails context is available.
+func New(dataDir string) *Service {
+	s := &Service{
+		vol:      0.7,
+		phaseVol: 1,
+		emitter:  events.Noop{},
+		sink:     NewSpeakerSink(),
+		state:    domain.AudioStatePayload{State: domain.AudioIdle},
+		index:    newFolderIndex(cachePath(dataDir, "folder-index.json")),
+		loudness: newLoudness(cachePath(dataDir, "loudness.json")),
+		shuffle:  newShuffler(cachePath(dataDir, "shuffle.json"), time.Now().UnixNano()),
+		bad:      newBadFiles(cachePath(dataDir, "bad-files.json")),
 	}
+	s.master, s.channels = newMixer(s.vol)
+	return s
 }
 
 // SetEmitter replaces the emitter (called from App.startup with the live Wails emitter).
@@ -46,167 +74,874 @@ func (s *Service) SetEmitter(e events.Emitter) {
 	s.emitter = e
 }
 
-// PlayLooping streams a single MP3 file in an infinite loop.
-func (s *Service) PlayLooping(filePath string) {
-	s.Stop()
-	stopCh := make(chan struct{})
+// SetSink replaces the output, the default device unless set (see NewSink).
+// Call it before anything plays.
+func (s *Service) SetSink(sink Sink) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sink = sink
+}
+
+// AudioDevices lists the output devices SetDevice accepts, starting with the
+// system default (ID ""). Only the speaker output has devices to choose from.
+// The error, e.g. ErrNoDeviceChoice, says why the list holds only the default.
+func (s *Service) AudioDevices() ([]domain.AudioDevice, error) {
 	s.mu.Lock()
-	s.stopCh = stopCh
+	sink := s.sink
 	s.mu.Unlock()
+	list := []domain.AudioDevice{{Name: "System default"}}
+	ds, ok := sink.(DeviceSink)
+	if !ok {
+		return list, nil
+	}
+	devices, err := ds.Devices()
+	return append(list, devices...), err
+}
 
-	trackName := filepath.Base(filePath)
-	s.emitState(domain.AudioPlaying, trackName, "Looping")
+// SetDevice plays to the output device with the given ID (see AudioDevices),
+// or the system default for "" or a device that is not connected.
+func (s *Service) SetDevice(id string) error {
+	s.mu.Lock()
+	sink := s.sink
+	s.mu.Unlock()
+	if ds, ok := sink.(DeviceSink); ok {
+		return ds.SetDevice(id)
+	}
+	return nil
+}
 
-	go func() {
-		for {
-			select {
-			case <-stopCh:
-				return
-			default:
-				if err := s.playFile(filePath, stopCh); err != nil {
-					s.emitState(domain.AudioStopped, trackName, "Error: "+err.Error())
-					return
-				}
-			}
-		}
-	}()
+// Close writes the pending shuffle history and closes the output; a WAV
+// recording is complete from then on. Playing again opens it anew.
+func (s *Service) Close() error {
+	s.shuffle.flush()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.outputOK {
+		return nil
+	}
+	s.outputOK = false
+	return s.sink.Close()
 }
 
-// PlayShuffleFolder scans a folder for MP3s, shuffles, and plays sequentially.
+// PlayLooping streams a single audio file in an infinite loop.
+func (s *Service) PlayLooping(filePath string) {
+	s.playTracks(s.newTrackList(filePath, []string{filePath}, false))
+}
+
+// PlayShuffleFolder scans a folder and its subfolders for audio files, shuffles,
+// and plays sequentially, crossfading between tracks when a crossfade is set (see SetFades).
 func (s *Service) PlayShuffleFolder(folder string) {
-	s.Stop()
-	tracks, err := scanMP3s(folder)
+	s.PlayFolder(folder, true, domain.ScanOptions{})
+}
+
+// PlayFolder plays the audio files found under folder (see domain.ScanOptions)
+// in path order or shuffled, repeating at the end.
+func (s *Service) PlayFolder(folder string, shuffle bool, opts domain.ScanOptions) {
+	tracks, err := s.index.tracks(folder, opts)
+	if err != nil || len(tracks) == 0 {
+		s.Stop()
+		s.emitState(domain.AudioStopped, "", "No audio files found")
+		return
+	}
+	s.playTracks(s.newTrackList(folder, tracks, shuffle))
+}
+
+// PlayPlaylist plays an M3U, M3U8 or PLS playlist in order or shuffled,
+// repeating at the end. Entries missing from disk are skipped and reported
+// in an "audioWarning" event.
+func (s *Service) PlayPlaylist(path string, shuffle bool) {
+	tracks, missing, err := parsePlaylist(path)
+	if len(missing) > 0 {
+		s.emitWarning(fmt.Sprintf("%s: skipped %d missing track(s)", filepath.Base(path), len(missing)), missing)
+	}
 	if err != nil || len(tracks) == 0 {
-		s.emitState(domain.AudioStopped, "", "No MP3s found")
+		s.Stop()
+		s.emitState(domain.AudioStopped, filepath.Base(path), "No playable tracks in playlist")
+		return
+	}
+	s.playTracks(s.newTrackList(path, tracks, shuffle))
+}
+
+// PlaySource plays a profile audio source on the music channel: generated
+// noise ("noise:pink") or tone ("tone:binaural:200:10"), an HTTP(S) stream,
+// a playlist, a folder scanned with opts, or a single file. Playlists and
+// folders shuffle when shuffle is set.
+func (s *Service) PlaySource(source string, shuffle bool, opts domain.ScanOptions) {
+	switch {
+	case isGenerated(source):
+		s.playGenerated(source)
+	case isStream(source):
+		s.PlayStream(source)
+	case isPlaylist(source):
+		s.PlayPlaylist(source, shuffle)
+	case isDir(source):
+		s.PlayFolder(source, shuffle, opts)
+	default:
+		s.PlayLooping(source)
+	}
+}
+
+// PlayNoise plays endless generated noise (see NoiseKinds) as the music.
+func (s *Service) PlayNoise(kind string) {
+	s.playGenerated(NoisePrefix + kind)
+}
+
+// PlayTone plays an endless binaural beat or isochronic tone as the music.
+func (s *Service) PlayTone(spec ToneSpec) {
+	s.playGenerated(spec.String())
+}
+
+// PlaySoundscape plays every layer of sc at once in place of the music. Each
+// layer has its own volume on top of the music volume (see SetLayerVolume).
+// Layers that fail (missing file, unknown noise) fall silent; the rest keep playing.
+func (s *Service) PlaySoundscape(sc domain.Soundscape) {
+	if len(sc.Layers) == 0 {
+		s.Stop()
+		s.emitState(domain.AudioStopped, sc.Name, "Empty soundscape")
+		return
+	}
+	if err := s.ensureOutput(); err != nil {
+		s.Stop()
+		s.emitState(domain.AudioStopped, sc.Name, "Error: "+err.Error())
 		return
 	}
+	s.startSoundscape(sc)
+	s.emitState(domain.AudioPlaying, sc.Name, fmt.Sprintf("Soundscape · %d layers", len(sc.Layers)))
+}
 
-	stopCh := make(chan struct{})
+// SetLayerVolume adjusts one layer of the playing soundscape (0–100) without
+// restarting it.
+func (s *Service) SetLayerVolume(index, v int) error {
 	s.mu.Lock()
-	s.stopCh = stopCh
+	defer s.mu.Unlock()
+	if index < 0 || index >= len(s.layers) {
+		return fmt.Errorf("no soundscape layer %d", index)
+	}
+	v = max(0, min(v, 100))
+	s.scapeLayers[index].Volume = v
+	s.state.Layers = append([]domain.SoundLayer(nil), s.scapeLayers...)
+	s.sink.Lock()
+	s.layers[index].setLevel(float64(v) / 100.0)
+	s.sink.Unlock()
+	return nil
+}
+
+// Stop halts the music with a short fade. Ambient layers and cues keep playing.
+func (s *Service) Stop() {
+	s.FadeOut(stopFade)
+}
+
+// FadeOut fades the music to silence over d, then stops it. Paused music is
+// already silent and stops at once.
+func (s *Service) FadeOut(d time.Duration) {
+	s.stopMusic(nil, d)
+}
+
+// stopMusic fades out the music as FadeOut does. With only set it does
+// nothing unless only is still the music run, so a run that fails late cannot
+// stop the source that replaced it; it reports whether it stopped.
+func (s *Service) stopMusic(only *run, d time.Duration) bool {
+	s.mu.Lock()
+	if only != nil && s.music != only {
+		s.mu.Unlock()
+		return false
+	}
+	r := s.music
+	s.music = nil
+	s.layers = nil
+	s.scapeLayers = nil
+	s.list = nil
+	if s.unpauseLocked() {
+		d = 0
+	}
 	s.mu.Unlock()
 
-	info := fmt.Sprintf("Shuffle folder · %d tracks", len(tracks))
-	shuffleStrings(tracks)
-	s.emitState(domain.AudioPlaying, filepath.Base(tracks[0]), info)
+	// Stop outside the lock; each track goroutine fades out and removes its own stream.
+	if r != nil {
+		r.stop(d)
+	}
+	s.shuffle.flush()
+	s.emitState(domain.AudioStopped, "", "")
+	return true
+}
+
+// PauseAudio holds the music where it is; ResumeAudio continues from the same
+// position. Ambient layers and cues keep playing. Does nothing when no music plays.
+func (s *Service) PauseAudio() {
+	s.mu.Lock()
+	if s.music == nil || s.paused {
+		s.mu.Unlock()
+		return
+	}
+	s.paused = true
+	s.sink.Lock()
+	s.channels[ChannelMusic].ctrl.Paused = true
+	s.sink.Unlock()
+	s.state.State = domain.AudioPaused
+	payload, emitter := s.stateLocked(), s.emitter
+	s.mu.Unlock()
+	emitter.Emit("audioStateChanged", payload)
+}
+
+// ResumeAudio continues music held by PauseAudio. It reports false when
+// nothing was paused, so the caller can start fresh audio instead.
+func (s *Service) ResumeAudio() bool {
+	s.mu.Lock()
+	if !s.unpauseLocked() {
+		s.mu.Unlock()
+		return false
+	}
+	s.state.State = domain.AudioPlaying
+	payload, emitter := s.stateLocked(), s.emitter
+	s.mu.Unlock()
+	emitter.Emit("audioStateChanged", payload)
+	return true
+}
+
+// NextTrack fades out the current track and plays the next one of the folder,
+// playlist or file (which restarts). Generated audio and soundscapes have no tracks.
+func (s *Service) NextTrack() error {
+	return s.stepTrack(1)
+}
+
+// PreviousTrack goes back one track, wrapping to the end of the list.
+func (s *Service) PreviousTrack() error {
+	return s.stepTrack(-1)
+}
+
+// TrackPrefs returns the shuffle preferences saved for a track file.
+func (s *Service) TrackPrefs(path string) domain.TrackPrefs {
+	return s.shuffle.get(path)
+}
+
+// CoverArt returns the picture embedded in the playing track as a data: URL,
+// or "" when it has none or path is no longer the playing track. It is kept
+// out of the state events so the picture is fetched once per track.
+func (s *Service) CoverArt(path string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.list == nil || s.list.meta == nil || s.list.meta.Path != path {
+		return ""
+	}
+	return s.list.cover
+}
+
+// SetTrackPrefs saves how shuffle treats a track file: its weight, whether it
+// is a favourite (picked more often) and whether it is banned (left out of
+// folders and playlists). The zero value resets it.
+func (s *Service) SetTrackPrefs(path string, p domain.TrackPrefs) error {
+	if path == "" {
+		return errors.New("no track given")
+	}
+	if p.Weight < 0 || math.IsNaN(p.Weight) || math.IsInf(p.Weight, 0) {
+		return fmt.Errorf("invalid track weight %v", p.Weight)
+	}
+	s.shuffle.set(path, p)
+
+	s.mu.Lock()
+	if s.list == nil || s.list.meta == nil || s.list.meta.Path != path {
+		s.mu.Unlock()
+		return nil
+	}
+	s.list.meta.Favorite = p.Favorite
+	payload, emitter := s.stateLocked(), s.emitter
+	s.mu.Unlock()
+	emitter.Emit("audioStateChanged", payload)
+	return nil
+}
+
+// BanTrack bans the playing track (see SetTrackPrefs) and skips to the next one.
+func (s *Service) BanTrack() error {
+	s.mu.Lock()
+	var track string
+	if s.list != nil && s.list.meta != nil {
+		track = s.list.meta.Path
+	}
+	s.mu.Unlock()
+	if track == "" {
+		return errors.New("no track is playing")
+	}
+	p := s.shuffle.get(track)
+	p.Banned = true
+	s.shuffle.set(track, p)
+	return s.NextTrack()
+}
+
+// BadFiles lists the files that could not be decoded. Folders and playlists
+// leave them out until they change on disk or ClearBadFiles is called.
+func (s *Service) BadFiles() []domain.TrackError {
+	return s.bad.list()
+}
+
+// ClearBadFiles forgets the bad files, so they are tried again.
+func (s *Service) ClearBadFiles() {
+	s.bad.clear()
+}
+
+// SetFades sets how long a new source fades in when nothing was playing and
+// how long sources crossfade: between shuffled tracks and when one source
+// replaces another (e.g. work music → break music).
+func (s *Service) SetFades(fadeIn, crossfade time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fadeIn = max(fadeIn, 0)
+	s.crossfade = max(crossfade, 0)
+}
+
+// SetNormalize turns loudness normalisation on or off for tracks that start
+// from now on. Each file is brought to a common loudness before the channel
+// volume, from its ReplayGain tags or a measurement cached in loudness.json.
+func (s *Service) SetNormalize(on bool) {
+	s.loudness.setEnabled(on)
+}
+
+// PlayAmbient loops a file on the ambient channel, underneath the music.
+func (s *Service) PlayAmbient(filePath string) {
+	r := newRun()
+	s.mu.Lock()
+	old := s.ambient
+	s.ambient = r
+	s.mu.Unlock()
+	if old != nil {
+		old.stop(stopFade)
+	}
 
 	go func() {
-		idx := 0
-		for {
-			select {
-			case <-stopCh:
+		for !r.stopped() {
+			if err := s.playFile(s.channels[ChannelAmbient], filePath, r, 0, 0); err != nil {
 				return
-			default:
-				track := tracks[idx%len(tracks)]
-				s.emitState(domain.AudioPlaying, filepath.Base(track), info)
-				_ = s.playFile(track, stopCh)
-				idx++
-				if idx%len(tracks) == 0 {
-					shuffleStrings(tracks)
-				}
 			}
 		}
 	}()
 }
 
-// Stop halts all playback immediately.
-func (s *Service) Stop() {
+// StopAmbient halts the ambient layer.
+func (s *Service) StopAmbient() {
 	s.mu.Lock()
-	ch := s.stopCh
-	s.stopCh = nil
-	s.volCtrl = nil
+	r := s.ambient
+	s.ambient = nil
 	s.mu.Unlock()
-
-	// Close the stop channel outside the lock so goroutines can exit.
-	if ch != nil {
-		close(ch)
+	if r != nil {
+		r.stop(stopFade)
 	}
-	// Clear any buffered audio in the speaker.
-	speaker.Clear()
-	s.emitState(domain.AudioStopped, "", "")
 }
 
-// SetVolume adjusts playback volume (0–100 from the frontend).
-// Updates take effect immediately on the currently-playing stream.
+// SetVolume adjusts the master music volume (0–100 from the frontend), which
+// scales the phase volume set by SetPhaseVolume. Updates take effect
+// immediately on the currently-playing stream, and a change is announced in
+// "audioStateChanged" so every control showing the volume follows it.
 func (s *Service) SetVolume(v int) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	if v < 0 {
 		v = 0
 	}
 	if v > 100 {
 		v = 100
 	}
-	s.vol = float64(v) / 100.0
-	if s.volCtrl != nil {
-		speaker.Lock()
-		s.volCtrl.Volume = linearToLog(s.vol)
-		s.volCtrl.Silent = s.vol == 0
-		speaker.Unlock()
+	vol := float64(v) / 100.0
+	if vol == s.vol {
+		s.mu.Unlock()
+		return
+	}
+	s.vol = vol
+	s.applyMusicLevelLocked()
+	payload := s.stateLocked()
+	emitter := s.emitter
+	s.mu.Unlock()
+	emitter.Emit("audioStateChanged", payload)
+}
+
+// SetPhaseVolume sets the music level of the current work or break phase as
+// a percentage (1–100) of the master volume; 0 means 100. It takes effect
+// immediately and lasts until the next call.
+func (s *Service) SetPhaseVolume(v int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v <= 0 || v > 100 {
+		v = 100
+	}
+	s.phaseVol = float64(v) / 100.0
+	s.applyMusicLevelLocked()
+}
+
+// applyMusicLevelLocked sets the music channel to the master times the phase
+// volume. Call with s.mu held.
+func (s *Service) applyMusicLevelLocked() {
+	s.sink.Lock()
+	s.channels[ChannelMusic].setLevel(s.vol * s.phaseVol)
+	s.sink.Unlock()
+}
+
+// SetEQ sets the tone of the music for the current work or break phase. It
+// takes effect immediately and lasts until the next call; the zero EQ plays
+// the music as it is.
+func (s *Service) SetEQ(eq domain.EQ) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eq = eq
+	s.applyFiltersLocked()
+}
+
+// SetMuffle turns the muffled mode on or off: a steep low-pass over the
+// music and ambient channels, on top of the phase EQ, that leaves the cues
+// clear. It lasts until turned off, across tracks and phases.
+func (s *Service) SetMuffle(on bool) {
+	s.mu.Lock()
+	if s.muffled == on {
+		s.mu.Unlock()
+		return
+	}
+	s.muffled = on
+	s.applyFiltersLocked()
+	payload := s.stateLocked()
+	emitter := s.emitter
+	s.mu.Unlock()
+	emitter.Emit("audioStateChanged", payload)
+}
+
+// Muffled reports whether the muffled mode is on.
+func (s *Service) Muffled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.muffled
+}
+
+// applyFiltersLocked sets the filters of the music and ambient channels from
+// the phase EQ and the muffle toggle. Call with s.mu held.
+func (s *Service) applyFiltersLocked() {
+	music := filterStages(s.eq, outputRate)
+	var ambient []biquad
+	if s.muffled {
+		music = append(music, muffleStages(outputRate)...)
+		ambient = muffleStages(outputRate)
+	}
+	s.sink.Lock()
+	s.channels[ChannelMusic].filters.set(music)
+	s.channels[ChannelAmbient].filters.set(ambient)
+	s.sink.Unlock()
+}
+
+// SetChannelVolume adjusts one mixer channel (see Channel* constants), 0–100.
+// For the music channel this is the master volume SetVolume controls.
+func (s *Service) SetChannelVolume(name string, v int) error {
+	if name == ChannelMusic {
+		s.SetVolume(v)
+		return nil
 	}
+	c, ok := s.channels[name]
+	if !ok {
+		return fmt.Errorf("unknown audio channel %q", name)
+	}
+	v = max(0, min(v, 100))
+	s.sink.Lock()
+	c.setLevel(float64(v) / 100.0)
+	s.sink.Unlock()
+	return nil
+}
+
+// PlayCue plays a short synthesised cue (see Cue* constants) on the cue channel,
+// on top of whatever is playing. Unknown names and missing audio devices are ignored.
+func (s *Service) PlayCue(name string) {
+	tone := cueTone(name)
+	if tone == nil || s.ensureOutput() != nil {
+		return
+	}
+	s.addStream(s.channels[ChannelCue], tone, cueRate)
+}
+
+// PlayChime plays an embedded completion chime (see Chimes) on the cue channel
+// at volume 0–100, independent of the music volume.
+func (s *Service) PlayChime(name string, volume int) error {
+	streamer, format, err := decodeChime(name)
+	if err != nil {
+		return err
+	}
+	if err := s.ensureOutput(); err != nil {
+		return nil // no device: nothing to hear, nothing to report
+	}
+	volume = max(0, min(volume, 100))
+	s.addStream(s.channels[ChannelCue], &effects.Volume{
+		Streamer: streamer,
+		Base:     2,
+		Volume:   linearToLog(float64(volume) / 100.0),
+		Silent:   volume == 0,
+	}, format.SampleRate)
+	return nil
 }
 
 // GetState returns the current audio state for the frontend.
 func (s *Service) GetState() domain.AudioStatePayload {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	return s.state
+	return s.stateLocked()
 }
 
 // ── internal ─────────────────────────────────────────────────────────────────
 
-func (s *Service) playFile(path string, stopCh chan struct{}) error {
-	// Bail out early if stop was already requested.
+// playTracks plays l on the music channel one track after another from l.idx,
+// repeating the list and reshuffling each pass when l.shuffle is set.
+// NextTrack and PreviousTrack step through the list; it stops once every track
+// has failed in a row.
+func (s *Service) playTracks(l *trackList) {
+	r := newTrackRun()
+	fadeIn := s.startMusic(r)
+	s.mu.Lock()
+	if s.music == r {
+		s.list = l
+	}
+	s.mu.Unlock()
+	s.emitState(domain.AudioPlaying, filepath.Base(l.tracks[l.idx]), l.info)
+	s.loudness.prepare(l.tracks)
+
+	go func() {
+		music := s.channels[ChannelMusic]
+		from := l.from
+		for failed := 0; !r.stopped(); {
+			s.mu.Lock()
+			s.dropBanned(l)
+			track, n := l.tracks[l.idx], len(l.tracks)
+			overlap := s.crossfade
+			s.mu.Unlock()
+
+			step := 1
+			meta, cover := readMeta(track)
+			f, err := s.startFile(music, track, r, fadeIn, overlap, from)
+			from = 0
+			if f != nil {
+				meta.DurationMs = f.length.Milliseconds()
+				s.shuffle.played(track)
+			}
+			meta.Path, meta.Favorite = track, s.shuffle.get(track).Favorite
+			s.mu.Lock()
+			l.track, l.meta, l.cover = f, &meta, cover
+			s.mu.Unlock()
+			if f != nil {
+				s.emitState(domain.AudioPlaying, filepath.Base(track), l.info)
+			}
+			switch {
+			case errors.Is(err, errNoOutput):
+				s.emitState(domain.AudioStopped, filepath.Base(track), "Error: "+err.Error())
+				return
+			case err != nil:
+				s.mu.Lock()
+				l.errors = append(l.errors, domain.TrackError{Path: track, Error: err.Error()})
+				if len(l.errors) > maxTrackErrors {
+					l.errors = l.errors[len(l.errors)-maxTrackErrors:]
+				}
+				s.mu.Unlock()
+				if failed++; failed >= n {
+					info := "Error: " + err.Error()
+					if n > 1 {
+						info = fmt.Sprintf("Error: none of the %d tracks could be played", n)
+					}
+					s.emitState(domain.AudioStopped, filepath.Base(track), info)
+					return
+				}
+				s.emitState(domain.AudioPlaying, filepath.Base(track), "Skipping: "+err.Error())
+				// Back off, so a run of broken files does not spin.
+				select {
+				case <-time.After(retryDelay(failed)):
+				case <-r.done:
+				case step = <-r.skip:
+				}
+			case f != nil:
+				failed = 0
+				fadeIn = overlap
+				select {
+				case <-f.fading:
+				case <-f.ended:
+				case <-r.done:
+				case step = <-r.skip:
+					fadeIn = max(overlap, stopFade)
+					s.fadeOut(f, fadeIn)
+				}
+			}
+
+			s.mu.Lock()
+			l.idx = (l.idx + step + n) % n
+			if l.shuffle && step > 0 && l.idx == 0 {
+				l.tracks = s.shuffle.order(l.tracks)
+			}
+			s.mu.Unlock()
+		}
+	}()
+}
+
+// retryDelay is the pause before the next track after failed tracks in a row
+// have failed: it doubles from trackRetry up to maxTrackRetry.
+func retryDelay(failed int) time.Duration {
+	return min(trackRetry<<min(failed-1, 16), maxTrackRetry)
+}
+
+// dropBanned removes the current track from l while it is banned and an
+// unbanned track is left, so a track banned mid-list is skipped when it comes
+// round. Call with s.mu held.
+func (s *Service) dropBanned(l *trackList) {
+	for s.shuffle.get(l.tracks[l.idx]).Banned && len(s.shuffle.playable(l.tracks)) < len(l.tracks) {
+		l.tracks = append(l.tracks[:l.idx:l.idx], l.tracks[l.idx+1:]...)
+		if l.idx == len(l.tracks) {
+			l.idx = 0
+		}
+		l.info = trackInfo(l.source, len(l.tracks), l.shuffle)
+	}
+}
+
+// stepTrack asks the playing track list to move by step tracks.
+func (s *Service) stepTrack(step int) error {
+	s.mu.Lock()
+	r := s.music
+	s.mu.Unlock()
+	if r == nil || r.skip == nil {
+		return errors.New("the current audio has no tracks to skip")
+	}
 	select {
-	case <-stopCh:
-		return nil
-	default:
+	case r.skip <- step:
+	default: // a skip is already pending
 	}
+	return nil
+}
+
+// startMusic makes r the music run, replacing the old one. The old run fades
+// out over the crossfade; the returned duration is how long r should fade in:
+// the crossfade when it replaces something, the session fade-in otherwise.
+// Paused music is dropped at once and the new source starts from silence.
+func (s *Service) startMusic(r *run) time.Duration {
+	s.mu.Lock()
+	old := s.music
+	s.music = r
+	s.layers = nil
+	s.scapeLayers = nil
+	s.list = nil
+	fadeIn, crossfade := s.fadeIn, s.crossfade
+	if s.unpauseLocked() {
+		crossfade = 0
+		if old != nil {
+			old.stop(0)
+			old = nil
+		}
+	}
+	s.mu.Unlock()
+
+	if old != nil {
+		old.stop(crossfade)
+		return crossfade
+	}
+	return fadeIn
+}
 
-	f, err := os.Open(path)
+// unpauseLocked releases the music channel if it is paused and reports
+// whether it was. Call with s.mu held.
+func (s *Service) unpauseLocked() bool {
+	if !s.paused {
+		return false
+	}
+	s.paused = false
+	s.sink.Lock()
+	s.channels[ChannelMusic].ctrl.Paused = false
+	s.sink.Unlock()
+	return true
+}
+
+// playGenerated plays an endless generated source on the music channel.
+func (s *Service) playGenerated(source string) {
+	gen, name, err := newGenerator(source)
+	if err == nil {
+		err = s.ensureOutput()
+	}
 	if err != nil {
-		return err
+		s.Stop()
+		s.emitState(domain.AudioStopped, "", "Error: "+err.Error())
+		return
 	}
-	defer f.Close()
 
-	streamer, format, err := mp3.Decode(f)
+	r := newRun()
+	fadeIn := s.startMusic(r)
+	stream := s.addFaded(s.channels[ChannelMusic], gen, outputRate, fadeIn)
+	s.emitState(domain.AudioPlaying, name, "Generated")
+	go func() {
+		<-r.done
+		s.fadeOut(stream, r.fade)
+	}()
+}
+
+// isGenerated reports whether source is synthesised rather than read from disk.
+func isGenerated(source string) bool {
+	return strings.HasPrefix(source, NoisePrefix) || strings.HasPrefix(source, TonePrefix)
+}
+
+// newGenerator builds the streamer for a generated source along with its display name.
+func newGenerator(source string) (beep.Streamer, string, error) {
+	if kind, ok := strings.CutPrefix(source, NoisePrefix); ok {
+		gen, err := newNoise(kind, outputRate, time.Now().UnixNano())
+		if err != nil {
+			return nil, "", err
+		}
+		return gen, strings.ToUpper(kind[:1]) + kind[1:] + " noise", nil
+	}
+	spec, err := ParseTone(source)
 	if err != nil {
-		return err
+		return nil, "", err
 	}
-	defer streamer.Close()
+	gen, err := newTone(spec, outputRate)
+	return gen, spec.label(), err
+}
 
-	_ = speaker.Init(format.SampleRate, format.SampleRate.N(time.Second/10))
+// startSoundscape nests one bus per layer inside the music channel and starts
+// a goroutine feeding each. Stopping the run fades the buses out together.
+func (s *Service) startSoundscape(sc domain.Soundscape) {
+	r := newRun()
+	fadeIn := s.startMusic(r)
+	music := s.channels[ChannelMusic]
+	layers := make([]*channel, len(sc.Layers))
+	buses := make([]*fader, len(sc.Layers))
+	for i, l := range sc.Layers {
+		layers[i] = newChannel(float64(max(0, min(l.Volume, 100))) / 100.0)
+		buses[i] = s.addFaded(music, &layers[i].vol, outputRate, fadeIn)
+	}
 
-	// Wrap streamer with volume control so the slider has audible effect.
 	s.mu.Lock()
-	vol := &effects.Volume{
-		Streamer: streamer,
-		Base:     2,
-		Volume:   linearToLog(s.vol),
-		Silent:   s.vol == 0,
+	if s.music == r {
+		s.layers = layers
+		s.scapeLayers = append([]domain.SoundLayer(nil), sc.Layers...)
 	}
-	s.volCtrl = vol
 	s.mu.Unlock()
 
-	// Check if stop was requested before starting playback
-	select {
-	case <-stopCh:
-		return nil
-	default:
+	for i, l := range sc.Layers {
+		go s.runLayer(layers[i], l, r)
 	}
+	go func() {
+		<-r.done
+		for _, b := range buses {
+			s.fadeOut(b, r.fade)
+		}
+	}()
+}
 
-	done := make(chan struct{})
-	speaker.Play(beep.Seq(vol, beep.Callback(func() {
-		close(done)
-	})))
+// runLayer feeds one soundscape layer into bus until r stops. Generated
+// sources and streams play forever; a file, folder or playlist plays once, or
+// repeats when Loop is set.
+func (s *Service) runLayer(bus *channel, l domain.SoundLayer, r *run) {
+	if isStream(l.Source) {
+		s.runStream(bus, l.Source, r, 0, nil)
+		return
+	}
+	if isGenerated(l.Source) {
+		gen, _, err := newGenerator(l.Source)
+		if err != nil {
+			return
+		}
+		stream := s.addFaded(bus, gen, outputRate, 0)
+		<-r.done
+		s.fadeOut(stream, r.fade)
+		return
+	}
+
+	tracks := []string{l.Source}
+	if isPlaylist(l.Source) {
+		var missing []string
+		if tracks, missing, _ = parsePlaylist(l.Source); len(missing) > 0 {
+			s.emitWarning(fmt.Sprintf("%s: skipped %d missing track(s)", filepath.Base(l.Source), len(missing)), missing)
+		}
+	} else if isDir(l.Source) {
+		var err error
+		if tracks, err = s.index.tracks(l.Source, domain.ScanOptions{}); err != nil {
+			return
+		}
+	}
+	tracks = s.bad.playable(tracks)
+	for len(tracks) > 0 {
+		if l.Shuffle {
+			tracks = s.shuffle.order(tracks)
+		}
+		played := false
+		for _, track := range tracks {
+			if r.stopped() {
+				return
+			}
+			if s.playFile(bus, track, r, 0, 0) == nil {
+				played = true
+			}
+		}
+		// Stop after one pass, or when nothing in it could be played.
+		if !l.Loop || !played {
+			return
+		}
+	}
+}
 
+// playFile decodes one file into bus, fading it in over fadeIn, and blocks
+// until it ends or r stops. With overlap set, the track fades out over its
+// last overlap and playFile returns as that fade begins, so the caller can
+// start the next track underneath it.
+func (s *Service) playFile(bus *channel, path string, r *run, fadeIn, overlap time.Duration) error {
+	track, err := s.startFile(bus, path, r, fadeIn, overlap, 0)
+	if track == nil {
+		return err
+	}
 	select {
-	case <-done:
-		return nil
-	case <-stopCh:
-		speaker.Clear()
-		return nil
+	case <-track.fading:
+	case <-track.ended:
+	case <-r.done:
+	}
+	return nil
+}
+
+// startFile decodes one file into bus from position from and returns its
+// stream without waiting (see playFile); a position past the end plays from
+// the start. The file is closed once its stream is gone. It returns a nil
+// stream when r has already stopped.
+func (s *Service) startFile(bus *channel, path string, r *run, fadeIn, overlap, from time.Duration) (*fader, error) {
+	// Bail out early if stop was already requested.
+	if r.stopped() {
+		return nil, nil
+	}
+
+	streamer, format, err := decodeFile(path)
+	if err != nil {
+		s.bad.add(path, err)
+		return nil, err
 	}
+	if err := s.ensureOutput(); err != nil {
+		streamer.Close()
+		return nil, err
+	}
+	if r.stopped() {
+		streamer.Close()
+		return nil, nil
+	}
+
+	n := streamer.Len()
+	length := format.SampleRate.D(n)
+	if skip := format.SampleRate.N(from); skip <= 0 || skip >= n || streamer.Seek(skip) != nil {
+		from = 0
+	} else {
+		n -= skip
+	}
+
+	var src beep.Streamer = streamer
+	if g := s.loudness.gain(path); g != 1 {
+		src = &effects.Gain{Streamer: streamer, Gain: g - 1}
+	}
+	track := s.addFaded(bus, src, format.SampleRate, fadeIn)
+	s.sink.Lock()
+	track.start, track.length = from, length
+	if overlap > 0 && n > 0 {
+		length := outputRate.N(format.SampleRate.D(n))
+		tail := min(outputRate.N(overlap), length/2)
+		track.fadeOutAt(length-tail, tail)
+	}
+	s.sink.Unlock()
+
+	go func() {
+		select {
+		case <-track.ended:
+		case <-r.done:
+			s.fadeOut(track, r.fade)
+			<-track.ended
+		}
+		streamer.Close()
+	}()
+	return track, nil
 }
 
 // linearToLog converts a linear volume (0.0–1.0) to a logarithmic gain
@@ -220,28 +955,62 @@ func linearToLog(v float64) float64 {
 
 func (s *Service) emitState(state domain.AudioPlaybackState, track, info string) {
 	s.mu.Lock()
+	if state == domain.AudioPlaying && s.paused {
+		state = domain.AudioPaused // e.g. a skip while paused names the new track
+	}
 	s.state = domain.AudioStatePayload{State: state, TrackName: track, TrackInfo: info}
-	payload := s.state
+	if state != domain.AudioStopped && s.scapeLayers != nil {
+		s.state.Layers = append([]domain.SoundLayer(nil), s.scapeLayers...)
+	}
+	if state != domain.AudioStopped && s.list != nil && s.list.meta != nil {
+		meta := *s.list.meta
+		s.state.Meta = &meta
+	}
+	if s.list != nil && len(s.list.errors) > 0 {
+		s.state.Errors = append([]domain.TrackError(nil), s.list.errors...)
+	}
+	payload := s.stateLocked()
 	emitter := s.emitter
 	s.mu.Unlock()
 	emitter.Emit("audioStateChanged", payload)
 }
 
-func scanMP3s(dir string) ([]string, error) {
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return nil, err
-	}
-	var files []string
-	for _, e := range entries {
-		if !e.IsDir() && strings.ToLower(filepath.Ext(e.Name())) == ".mp3" {
-			files = append(files, filepath.Join(dir, e.Name()))
+// stateLocked returns the state with the elapsed time of the current track
+// brought up to date. Call with s.mu held.
+func (s *Service) stateLocked() domain.AudioStatePayload {
+	st := s.state
+	st.Muffled = s.muffled
+	st.Volume = int(math.Round(s.vol * 100))
+	if st.Meta != nil {
+		meta := *st.Meta
+		if s.list != nil {
+			meta.ElapsedMs = s.list.position(s.sink).Milliseconds()
 		}
+		st.Meta = &meta
 	}
-	return files, nil
+	return st
 }
 
-func shuffleStrings(sl []string) {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	r.Shuffle(len(sl), func(i, j int) { sl[i], sl[j] = sl[j], sl[i] })
+// emitWarning reports a non-fatal playback problem, e.g. missing playlist entries.
+func (s *Service) emitWarning(message string, paths []string) {
+	s.mu.Lock()
+	emitter := s.emitter
+	s.mu.Unlock()
+	emitter.Emit("audioWarning", map[string]interface{}{
+		"message": message,
+		"paths":   paths,
+	})
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// cachePath joins a cache file name onto dataDir, or returns "" without one.
+func cachePath(dataDir, name string) string {
+	if dataDir == "" {
+		return ""
+	}
+	return filepath.Join(dataDir, name)
 }
@@ -1,23 +1,26 @@
 package audio
 
 import (
+	"math"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"focusplay/internal/domain"
+
+	"github.com/gopxl/beep"
 )
 
 func TestNewReturnsService(t *testing.T) {
-	svc := New()
+	svc := New("")
 	if svc == nil {
-		t.Fatal("New() returned nil")
+		t.Fatal("New returned nil")
 	}
 }
 
 func TestInitialStateIsIdle(t *testing.T) {
-	svc := New()
+	svc := New("")
 	state := svc.GetState()
 	if state.State != domain.AudioIdle {
 		t.Errorf("Initial state: want %q, got %q", domain.AudioIdle, state.State)
@@ -28,7 +31,7 @@ func TestInitialStateIsIdle(t *testing.T) {
 }
 
 func TestSetVolumeClamps(t *testing.T) {
-	svc := New()
+	svc := New("")
 	tests := []struct {
 		in   int
 		want float64
@@ -48,15 +51,27 @@ func TestSetVolumeClamps(t *testing.T) {
 }
 
 func TestSetVolumeDoesNotChangeState(t *testing.T) {
-	svc := New()
+	svc := New("")
 	svc.SetVolume(60)
 	if svc.GetState().State != domain.AudioIdle {
 		t.Error("SetVolume must not alter playback state")
 	}
 }
 
+func TestSetVolumeAnnouncesChanges(t *testing.T) {
+	svc := New("")
+	log := &eventLog{}
+	svc.SetEmitter(log)
+	svc.SetVolume(40)
+	svc.SetVolume(40)
+	states := log.events["audioStateChanged"]
+	if len(states) != 1 || states[0].(domain.AudioStatePayload).Volume != 40 || svc.GetState().Volume != 40 {
+		t.Errorf("want one state at volume 40, got %+v", states)
+	}
+}
+
 func TestStopFromIdle(t *testing.T) {
-	svc := New()
+	svc := New("")
 	svc.Stop() // must not panic
 	if svc.GetState().State != domain.AudioStopped {
 		t.Errorf("After Stop(), want %q, got %q", domain.AudioStopped, svc.GetState().State)
@@ -64,7 +79,7 @@ func TestStopFromIdle(t *testing.T) {
 }
 
 func TestDoubleStop(t *testing.T) {
-	svc := New()
+	svc := New("")
 	svc.Stop()
 	svc.Stop() // second stop must be a no-op
 	if svc.GetState().State != domain.AudioStopped {
@@ -73,7 +88,7 @@ func TestDoubleStop(t *testing.T) {
 }
 
 func TestPlayLoopingMissingFileNocrash(t *testing.T) {
-	svc := New()
+	svc := newTestOutput()
 	defer func() {
 		if r := recover(); r != nil {
 			t.Errorf("PlayLooping panicked on missing file: %v", r)
@@ -88,7 +103,7 @@ func TestPlayLoopingMissingFileNocrash(t *testing.T) {
 }
 
 func TestPlayShuffleFolderMissingFolderNocrash(t *testing.T) {
-	svc := New()
+	svc := newTestOutput()
 	defer func() {
 		if r := recover(); r != nil {
 			t.Errorf("PlayShuffleFolder panicked on missing folder: %v", r)
@@ -100,8 +115,17 @@ func TestPlayShuffleFolderMissingFolderNocrash(t *testing.T) {
 	}
 }
 
+func TestPlayNoiseUnknownKindStops(t *testing.T) {
+	svc := newTestOutput()
+	svc.PlayNoise("purple")
+	state := svc.GetState()
+	if state.State != domain.AudioStopped || state.TrackInfo == "" {
+		t.Errorf("unknown noise: want stopped with error, got %+v", state)
+	}
+}
+
 func TestPlayShuffleFolderEmptyFolder(t *testing.T) {
-	svc := New()
+	svc := newTestOutput()
 	svc.PlayShuffleFolder(t.TempDir()) // no .mp3 files
 	if svc.GetState().State != domain.AudioStopped {
 		t.Errorf("Empty folder: want %q, got %q", domain.AudioStopped, svc.GetState().State)
@@ -114,7 +138,7 @@ func TestPlayLoopingInvalidMp3NocrashNoDownload(t *testing.T) {
 	// Minimal fake MP3 header — beep will return a decode error, not panic
 	_ = os.WriteFile(mp3Path, []byte{0xFF, 0xFB, 0x10, 0x00}, 0644)
 
-	svc := New()
+	svc := newTestOutput()
 	defer func() {
 		if r := recover(); r != nil {
 			t.Errorf("PlayLooping panicked with invalid MP3: %v", r)
@@ -127,3 +151,196 @@ func TestPlayLoopingInvalidMp3NocrashNoDownload(t *testing.T) {
 		t.Logf("State after decode failure: %q", svc.GetState().State)
 	}
 }
+
+func TestCueToneIsShortAndAudible(t *testing.T) {
+	tone := cueTone(CueWarning)
+	if tone == nil {
+		t.Fatal("CueWarning has no tone")
+	}
+	buf := make([][2]float64, 512)
+	total, peak := 0, 0.0
+	for {
+		n, ok := tone.Stream(buf)
+		for _, s := range buf[:n] {
+			peak = math.Max(peak, math.Abs(s[0]))
+		}
+		total += n
+		if !ok {
+			break
+		}
+	}
+	if d := cueRate.D(total); d < 200*time.Millisecond || d > time.Second {
+		t.Errorf("warning cue length: want 0.2–1 s, got %v", d)
+	}
+	if peak < 0.1 || peak > 1 {
+		t.Errorf("warning cue peak: want audible and unclipped, got %.2f", peak)
+	}
+}
+
+func TestPlayCueUnknownNocrash(t *testing.T) {
+	svc := newTestOutput()
+	svc.PlayCue("no-such-cue")
+	svc.PlayCue(CueWarning)
+}
+
+func TestEmbeddedChimesDecode(t *testing.T) {
+	names := Chimes()
+	if len(names) < 2 {
+		t.Fatalf("want several embedded chimes, got %v", names)
+	}
+	for _, name := range names {
+		st, format, err := decodeChime(name)
+		if err != nil {
+			t.Errorf("decodeChime(%q): %v", name, err)
+			continue
+		}
+		buf := make([][2]float64, format.SampleRate.N(time.Second))
+		if n, _ := st.Stream(buf); n < format.SampleRate.N(500*time.Millisecond) {
+			t.Errorf("chime %q: want at least 0.5 s of audio, got %d samples", name, n)
+		}
+	}
+}
+
+func TestPlayChimeUnknownFallsBack(t *testing.T) {
+	svc := newTestOutput()
+	if err := svc.PlayChime("no-such-chime", 80); err != nil {
+		t.Errorf("unknown chime should fall back to %q, got %v", DefaultChime, err)
+	}
+}
+
+// constant streams a fixed sample value forever.
+func constant(v float64) beep.Streamer {
+	return beep.StreamerFunc(func(samples [][2]float64) (int, bool) {
+		for i := range samples {
+			samples[i] = [2]float64{v, v}
+		}
+		return len(samples), true
+	})
+}
+
+// pull reads n samples from the master mixer and returns the last left value.
+func pull(svc *Service, n int) float64 {
+	buf := make([][2]float64, n)
+	svc.sink.Lock()
+	svc.master.Stream(buf)
+	svc.sink.Unlock()
+	return buf[n-1][0]
+}
+
+func TestChannelsMixWithIndependentVolumes(t *testing.T) {
+	svc := newTestOutput()
+	music := svc.addStream(svc.channels[ChannelMusic], constant(0.2), outputRate)
+	svc.addStream(svc.channels[ChannelCue], constant(0.1), outputRate)
+
+	if got := pull(svc, 64); math.Abs(got-0.3) > 1e-9 {
+		t.Errorf("music+cue: want 0.3, got %.4f", got)
+	}
+
+	if err := svc.SetChannelVolume(ChannelCue, 50); err != nil {
+		t.Fatalf("SetChannelVolume: %v", err)
+	}
+	if got := pull(svc, 64); math.Abs(got-0.25) > 1e-9 {
+		t.Errorf("cue at 50%%: want 0.25, got %.4f", got)
+	}
+
+	svc.removeStream(music)
+	if got := pull(svc, 64); math.Abs(got-0.05) > 1e-9 {
+		t.Errorf("after removing music: want 0.05, got %.4f", got)
+	}
+}
+
+func TestPhaseVolumeScalesWithMaster(t *testing.T) {
+	svc := newTestOutput()
+	svc.addStream(svc.channels[ChannelMusic], constant(0.5), outputRate)
+
+	svc.SetPhaseVolume(50)
+	if got := pull(svc, 64); math.Abs(got-0.25) > 1e-9 {
+		t.Errorf("phase at 50%%: want 0.25, got %.4f", got)
+	}
+	svc.SetVolume(50)
+	if got := pull(svc, 64); math.Abs(got-0.125) > 1e-9 {
+		t.Errorf("master at 50%% on top: want 0.125, got %.4f", got)
+	}
+	svc.SetPhaseVolume(0) // unset: the full master volume
+	if got := pull(svc, 64); math.Abs(got-0.25) > 1e-9 {
+		t.Errorf("phase unset: want 0.25, got %.4f", got)
+	}
+}
+
+func TestAddStreamResamplesToOutputRate(t *testing.T) {
+	svc := newTestOutput()
+	// One second of audio at 22.05 kHz should last one second at the output rate.
+	src := beep.Take(22050, constant(0.5))
+	svc.addStream(svc.channels[ChannelMusic], src, 22050)
+
+	buf := make([][2]float64, outputRate.N(time.Second)+outputRate.N(100*time.Millisecond))
+	svc.master.Stream(buf)
+	if v := buf[outputRate.N(900*time.Millisecond)][0]; math.Abs(v-0.5) > 0.01 {
+		t.Errorf("at 0.9 s: want 0.5, got %.3f", v)
+	}
+	if v := buf[len(buf)-1][0]; v != 0 {
+		t.Errorf("after 1.1 s: want silence, got %.3f", v)
+	}
+}
+
+func TestSetChannelVolumeUnknown(t *testing.T) {
+	if err := New("").SetChannelVolume("nope", 50); err == nil {
+		t.Error("expected error for unknown channel")
+	}
+}
+
+func TestSoundscapeLayersMixWithOwnVolumes(t *testing.T) {
+	svc := newTestOutput()
+	svc.startSoundscape(domain.Soundscape{Name: "Test", Layers: []domain.SoundLayer{
+		{Source: NoisePrefix + NoiseWhite, Volume: 0},
+		{Source: NoisePrefix + NoiseBrown, Volume: 0},
+	}})
+	time.Sleep(20 * time.Millisecond) // let the layer goroutines attach their generators
+
+	if got := rms(svc, 4096); got != 0 {
+		t.Fatalf("both layers muted: want silence, got rms %.4f", got)
+	}
+	if err := svc.SetLayerVolume(0, 100); err != nil {
+		t.Fatalf("SetLayerVolume: %v", err)
+	}
+	if got := rms(svc, 4096); got < 0.05 {
+		t.Errorf("white layer at 100%%: want audible, got rms %.4f", got)
+	}
+	if err := svc.SetLayerVolume(2, 50); err == nil {
+		t.Error("expected error for a layer index out of range")
+	}
+	if got := svc.GetState().Layers; len(got) != 2 || got[0].Volume != 100 {
+		t.Errorf("state should report live layer volumes, got %+v", got)
+	}
+
+	svc.Stop()
+	time.Sleep(20 * time.Millisecond)
+	pull(svc, outputRate.N(stopFade)) // let the stop fade run out
+	if got := rms(svc, 4096); got != 0 {
+		t.Errorf("after Stop: want silence, got rms %.4f", got)
+	}
+	if err := svc.SetLayerVolume(0, 50); err == nil {
+		t.Error("SetLayerVolume must fail once the soundscape stopped")
+	}
+}
+
+func TestPlaySoundscapeEmpty(t *testing.T) {
+	svc := newTestOutput()
+	svc.PlaySoundscape(domain.Soundscape{Name: "Nothing"})
+	if st := svc.GetState(); st.State != domain.AudioStopped || st.TrackInfo != "Empty soundscape" {
+		t.Errorf("want stopped with an explanation, got %+v", st)
+	}
+}
+
+// rms pulls n samples from the master mixer and returns their left-channel RMS.
+func rms(svc *Service, n int) float64 {
+	buf := make([][2]float64, n)
+	svc.sink.Lock()
+	svc.master.Stream(buf)
+	svc.sink.Unlock()
+	var sum float64
+	for _, s := range buf {
+		sum += s[0] * s[0]
+	}
+	return math.Sqrt(sum / float64(n))
+}
@@ -0,0 +1,175 @@
+package audio
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"focusplay/internal/domain"
+	"focusplay/internal/infra/storage"
+)
+
+// historySize is how many plays the shuffle remembers across sessions.
+const historySize = 500
+
+// favoriteWeight multiplies the weight of favourite tracks.
+const favoriteWeight = 3
+
+// historyFlush is how long new plays may wait before shuffle.json is written,
+// so quick skips through short tracks do not each cost a write.
+var historyFlush = 30 * time.Second
+
+// shuffler orders track lists. Each pass is a weighted random permutation
+// (favourites and heavier tracks tend to come early) with recently played
+// tracks held back to the end, so nothing repeats soon, even across a pass
+// boundary or a restart. History and per-track preferences are kept in
+// shuffle.json.
+type shuffler struct {
+	mu      sync.Mutex
+	path    string // "" keeps them in memory only
+	rng     *rand.Rand
+	history []string // most recent last
+	prefs   map[string]domain.TrackPrefs
+	dirty   bool        // plays not yet written
+	flushAt *time.Timer // pending write of the plays, nil when none
+}
+
+type shuffleFile struct {
+	History []string                     `json:"history"`
+	Prefs   map[string]domain.TrackPrefs `json:"prefs"`
+}
+
+// newShuffler loads shuffle state from filePath and draws from a source
+// seeded with seed, so tests get the same order every run.
+func newShuffler(filePath string, seed int64) *shuffler {
+	sh := &shuffler{path: filePath, rng: rand.New(rand.NewSource(seed))}
+	var f shuffleFile
+	if filePath != "" {
+		_ = storage.Load(filePath, &f)
+	}
+	sh.history = f.History
+	sh.prefs = f.Prefs
+	if sh.prefs == nil {
+		sh.prefs = map[string]domain.TrackPrefs{}
+	}
+	return sh
+}
+
+// order returns a new play order for tracks.
+func (sh *shuffler) order(tracks []string) []string {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	// Weighted random permutation (Efraimidis–Spirakis): sort by u^(1/w).
+	keys := make(map[string]float64, len(tracks))
+	for _, t := range tracks {
+		keys[t] = math.Pow(sh.rng.Float64(), 1/sh.weightLocked(t))
+	}
+	out := append([]string(nil), tracks...)
+	sort.SliceStable(out, func(i, j int) bool { return keys[out[i]] > keys[out[j]] })
+
+	// Hold back the most recently played half, least recent first.
+	inList := make(map[string]bool, len(tracks))
+	for _, t := range tracks {
+		inList[t] = true
+	}
+	recent := map[string]int{} // track → plays since (0 = just played)
+	for i := len(sh.history) - 1; i >= 0 && len(recent) < len(tracks)/2; i-- {
+		if t := sh.history[i]; inList[t] {
+			if _, seen := recent[t]; !seen {
+				recent[t] = len(sh.history) - 1 - i
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		ri, oki := recent[out[i]]
+		rj, okj := recent[out[j]]
+		if oki != okj {
+			return okj // not recently played comes first
+		}
+		return oki && ri > rj
+	})
+	return out
+}
+
+func (sh *shuffler) weightLocked(track string) float64 {
+	p := sh.prefs[track]
+	w := 1.0
+	if p.Weight > 0 {
+		w = p.Weight
+	}
+	if p.Favorite {
+		w *= favoriteWeight
+	}
+	return w
+}
+
+// playable drops banned tracks, unless that would leave nothing to play.
+func (sh *shuffler) playable(tracks []string) []string {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	var out []string
+	for _, t := range tracks {
+		if !sh.prefs[t].Banned {
+			out = append(out, t)
+		}
+	}
+	if len(out) == 0 {
+		return tracks
+	}
+	return out
+}
+
+// played records that track has started.
+func (sh *shuffler) played(track string) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	sh.history = append(sh.history, track)
+	if len(sh.history) > historySize {
+		sh.history = append([]string(nil), sh.history[len(sh.history)-historySize:]...)
+	}
+	sh.dirty = true
+	if sh.path != "" && sh.flushAt == nil {
+		sh.flushAt = time.AfterFunc(historyFlush, sh.flush)
+	}
+}
+
+// flush writes plays recorded since the last write, if any.
+func (sh *shuffler) flush() {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if sh.dirty {
+		sh.saveLocked()
+	}
+}
+
+func (sh *shuffler) get(track string) domain.TrackPrefs {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	return sh.prefs[track]
+}
+
+func (sh *shuffler) set(track string, p domain.TrackPrefs) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if p == (domain.TrackPrefs{}) {
+		delete(sh.prefs, track)
+	} else {
+		sh.prefs[track] = p
+	}
+	sh.saveLocked()
+}
+
+// saveLocked writes everything, plays included, and cancels a pending flush.
+func (sh *shuffler) saveLocked() {
+	if sh.flushAt != nil {
+		sh.flushAt.Stop()
+		sh.flushAt = nil
+	}
+	sh.dirty = false
+	if sh.path != "" {
+		_ = storage.Save(sh.path, shuffleFile{History: sh.history, Prefs: sh.prefs})
+	}
+}
@@ -0,0 +1,154 @@
+package audio
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"focusplay/internal/domain"
+)
+
+func TestShuffleIsDeterministicUnderSeed(t *testing.T) {
+	tracks := []string{"a", "b", "c", "d", "e", "f"}
+	one, two := newShuffler("", 42), newShuffler("", 42)
+	for pass := 0; pass < 5; pass++ {
+		if a, b := one.order(tracks), two.order(tracks); !reflect.DeepEqual(a, b) {
+			t.Fatalf("pass %d: same seed gave %v and %v", pass, a, b)
+		}
+	}
+}
+
+func TestShuffleNeverRepeatsAcrossPasses(t *testing.T) {
+	for _, n := range []int{2, 3, 7} {
+		var tracks []string
+		for i := 0; i < n; i++ {
+			tracks = append(tracks, string(rune('a'+i)))
+		}
+		sh := newShuffler("", 1)
+		last := ""
+		for pass := 0; pass < 200; pass++ {
+			order := sh.order(tracks)
+			if order[0] == last {
+				t.Fatalf("%d tracks, pass %d: %q played twice in a row", n, pass, last)
+			}
+			for _, tr := range order {
+				sh.played(tr)
+			}
+			last = order[n-1]
+		}
+	}
+}
+
+func TestShuffleFavoritesComeEarly(t *testing.T) {
+	tracks := []string{"a", "b", "c", "d"}
+	sh := newShuffler("", 7)
+	sh.set("c", domain.TrackPrefs{Favorite: true})
+	first := map[string]int{}
+	for i := 0; i < 3000; i++ {
+		first[sh.order(tracks)[0]]++
+	}
+	// Weight 3 against three tracks of weight 1: c leads half the time.
+	if got := first["c"]; got < 1350 || got > 1650 {
+		t.Errorf("favourite first %d times of 3000, want ~1500 (%v)", got, first)
+	}
+}
+
+func TestShuffleSkipsBannedTracks(t *testing.T) {
+	sh := newShuffler("", 1)
+	sh.set("b", domain.TrackPrefs{Banned: true})
+	if got := sh.playable([]string{"a", "b", "c"}); !reflect.DeepEqual(got, []string{"a", "c"}) {
+		t.Errorf("got %v", got)
+	}
+	if got := sh.playable([]string{"b"}); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("all banned: want the list unchanged, got %v", got)
+	}
+}
+
+func TestShuffleHistoryPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shuffle.json")
+	sh := newShuffler(path, 1)
+	sh.played("a")
+	sh.played("b")
+	sh.set("d", domain.TrackPrefs{Weight: 2})
+
+	sh = newShuffler(path, 2)
+	if got := sh.get("d"); got.Weight != 2 {
+		t.Errorf("prefs: got %+v", got)
+	}
+	// The two most recent plays go last, least recent first.
+	if order := sh.order([]string{"a", "b", "c", "d"}); !reflect.DeepEqual(order[2:], []string{"a", "b"}) {
+		t.Errorf("got %v", order)
+	}
+}
+
+func TestShuffleHistoryWritesAreBatched(t *testing.T) {
+	old := historyFlush
+	historyFlush = 50 * time.Millisecond
+	t.Cleanup(func() { historyFlush = old })
+	path := filepath.Join(t.TempDir(), "shuffle.json")
+	sh := newShuffler(path, 1)
+
+	sh.played("a")
+	sh.played("b")
+	if _, err := os.Stat(path); err == nil {
+		t.Fatal("plays should not be written at once")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(newShuffler(path, 1).history) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("plays never written")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	sh.played("c")
+	sh.flush()
+	if got := newShuffler(path, 1).history; len(got) != 3 {
+		t.Errorf("flush: want 3 plays on disk, got %v", got)
+	}
+}
+
+func TestBanTrackSkipsAndLeavesItOut(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.wav", "b.wav", "c.wav"} {
+		writeWAV(t, filepath.Join(dir, name))
+	}
+	svc := newTestOutput()
+	if err := svc.BanTrack(); err == nil {
+		t.Error("BanTrack: want an error with nothing playing")
+	}
+	svc.SetTrackPrefs(filepath.Join(dir, "c.wav"), domain.TrackPrefs{Banned: true})
+	svc.PlayFolder(dir, false, domain.ScanOptions{})
+	waitStreams(t, svc, 1)
+	for svc.GetState().Meta == nil {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if name := svc.GetState().TrackName; name != "a.wav" {
+		t.Fatalf("want a.wav first, got %q", name)
+	}
+
+	if err := svc.BanTrack(); err != nil {
+		t.Fatalf("BanTrack: %v", err)
+	}
+	pull(svc, outputRate.N(stopFade)+1000)
+	waitTrack(t, svc, "b.wav")
+	waitStreams(t, svc, 1)
+
+	// At the end of b.wav the list comes round to a.wav, which is dropped.
+	pull(svc, 6000)
+	deadline := time.Now().Add(2 * time.Second)
+	for len(svc.Playback().Tracks) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("want only b.wav left, got %v", svc.Playback().Tracks)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if st := svc.GetState(); st.TrackName != "b.wav" {
+		t.Errorf("want b.wav again, got %q", st.TrackName)
+	}
+	if !svc.TrackPrefs(filepath.Join(dir, "a.wav")).Banned {
+		t.Error("a.wav should be banned")
+	}
+}
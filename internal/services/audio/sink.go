@@ -0,0 +1,304 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"focusplay/internal/domain"
+
+	"github.com/gopxl/beep"
+	"github.com/gopxl/beep/speaker"
+)
+
+// Sink is where the master mixer ends up: the sound card, nowhere, or a file.
+// Service opens it on the first play and never changes the streamer it
+// pulls; Lock guards that streamer, so hold it while changing mixers, faders
+// or volumes.
+type Sink interface {
+	// Open starts pulling st at sample rate sr.
+	Open(st beep.Streamer, sr beep.SampleRate) error
+	Lock()
+	Unlock()
+	// Close stops pulling. The speaker and null sinks can be opened again
+	// afterwards; a WAV recording is finished.
+	Close() error
+}
+
+// DeviceSink is a Sink that can play to a chosen output device.
+type DeviceSink interface {
+	Sink
+	// Devices lists the output devices besides the system default.
+	Devices() ([]domain.AudioDevice, error)
+	// SetDevice picks the device by ID; "" is the system default.
+	SetDevice(id string) error
+}
+
+// NewSink returns the sink named by spec: "speaker" (or "") for the default
+// output device, "null" to discard the sound, or "wav:<path>" to record it.
+func NewSink(spec string) (Sink, error) {
+	kind, arg, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "", "speaker":
+		return NewSpeakerSink(), nil
+	case "null":
+		return NewNullSink(), nil
+	case "wav":
+		if arg == "" {
+			return nil, errors.New("wav output needs a file: wav:<path>")
+		}
+		return NewWAVSink(arg)
+	}
+	return nil, fmt.Errorf("unknown audio output %q", spec)
+}
+
+// SpeakerSink plays through an output device via beep's speaker package: the
+// system default, or the one chosen with SetDevice.
+type SpeakerSink struct {
+	mu     sync.Mutex
+	device string // chosen device ID, "" for the system default
+}
+
+// speakerReady is set once speaker.Init has succeeded; the speaker cannot be
+// initialised twice in one process, so a reopened SpeakerSink reuses it.
+var speakerReady bool
+
+func NewSpeakerSink() *SpeakerSink {
+	return &SpeakerSink{}
+}
+
+func (s *SpeakerSink) Open(st beep.Streamer, sr beep.SampleRate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !speakerReady {
+		if err := routeOutput(availableDevice(s.device), false); err != nil {
+			return err
+		}
+		if err := speaker.Init(sr, sr.N(time.Second/10)); err != nil {
+			return err
+		}
+		speakerReady = true
+	}
+	speaker.Play(st)
+	return nil
+}
+
+func (s *SpeakerSink) Lock()   { speaker.Lock() }
+func (s *SpeakerSink) Unlock() { speaker.Unlock() }
+
+func (s *SpeakerSink) Close() error {
+	speaker.Clear()
+	return nil
+}
+
+// Devices lists the output devices besides the system default.
+func (s *SpeakerSink) Devices() ([]domain.AudioDevice, error) {
+	return outputDevices()
+}
+
+// SetDevice sends the sound to the device with the given ID, moving it over
+// at once when the output is open. "" or a device that is not connected
+// means the system default. Where devices cannot be chosen at all, any ID but
+// "" fails with ErrNoDeviceChoice.
+func (s *SpeakerSink) SetDevice(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id == s.device {
+		return nil
+	}
+	if id != "" {
+		if _, err := outputDevices(); errors.Is(err, ErrNoDeviceChoice) {
+			return err
+		}
+	}
+	s.device = id
+	if !speakerReady {
+		return nil // picked up by Open
+	}
+	return routeOutput(availableDevice(id), true)
+}
+
+// clockedSink pulls its streamer in real time, as a sound card would, and
+// hands each buffer to write.
+type clockedSink struct {
+	mu    sync.Mutex
+	st    beep.Streamer
+	write func([][2]float64) error
+	stop  chan struct{}
+	done  chan struct{}
+	err   error // first write error, returned by Close
+}
+
+// clockTick is how often a clockedSink catches up with the wall clock.
+const clockTick = 10 * time.Millisecond
+
+func (c *clockedSink) Open(st beep.Streamer, sr beep.SampleRate) error {
+	if c.stop != nil {
+		return errors.New("audio output already open")
+	}
+	c.st = st
+	c.stop, c.done = make(chan struct{}), make(chan struct{})
+	go c.run(sr)
+	return nil
+}
+
+func (c *clockedSink) run(sr beep.SampleRate) {
+	defer close(c.done)
+	tick := time.NewTicker(clockTick)
+	defer tick.Stop()
+	buf := make([][2]float64, sr.N(time.Second/10))
+	start, pulled := time.Now(), 0
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-tick.C:
+		}
+		for due := sr.N(time.Since(start)) - pulled; due > 0; {
+			n := min(due, len(buf))
+			c.mu.Lock()
+			got, _ := c.st.Stream(buf[:n])
+			c.mu.Unlock()
+			clear(buf[got:n])
+			if c.write != nil && c.err == nil {
+				c.err = c.write(buf[:n])
+			}
+			pulled += n
+			due -= n
+		}
+	}
+}
+
+func (c *clockedSink) Lock()   { c.mu.Lock() }
+func (c *clockedSink) Unlock() { c.mu.Unlock() }
+
+func (c *clockedSink) Close() error {
+	if c.stop == nil {
+		return nil
+	}
+	close(c.stop)
+	<-c.done
+	c.stop, c.done = nil, nil
+	return c.err
+}
+
+// NullSink plays to nowhere, at the pace of a real device, so tracks still
+// end and advance on a machine without a sound card.
+type NullSink struct {
+	clockedSink
+}
+
+func NewNullSink() *NullSink {
+	return &NullSink{}
+}
+
+// maxWAVData is the most sample data a WAV file can describe: its sizes are
+// 32-bit, so a recording stops at about 4 GiB, some 6¾ hours at 44.1 kHz.
+var maxWAVData uint32 = (math.MaxUint32 - 36) &^ 3
+
+// WAVSink records the output to a 16-bit stereo WAV file in real time. The
+// file is only complete once the sink is closed. Once it reaches maxWAVData
+// the recording stops while the output keeps running.
+type WAVSink struct {
+	clockedSink
+	f    *os.File
+	w    *bufio.Writer
+	sr   beep.SampleRate
+	data uint32 // bytes of samples written
+}
+
+// NewWAVSink creates (or truncates) the file at path.
+func NewWAVSink(path string) (*WAVSink, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &WAVSink{f: f, w: bufio.NewWriter(f)}
+	s.write = s.writeSamples
+	return s, nil
+}
+
+func (s *WAVSink) Open(st beep.Streamer, sr beep.SampleRate) error {
+	if s.f == nil {
+		return errors.New("wav recording already closed")
+	}
+	s.sr = sr
+	if err := s.writeHeader(); err != nil {
+		return err
+	}
+	return s.clockedSink.Open(st, sr)
+}
+
+func (s *WAVSink) writeSamples(buf [][2]float64) error {
+	buf = buf[:min(len(buf), int((maxWAVData-s.data)/4))]
+	var frame [4]byte
+	for _, smp := range buf {
+		for c := 0; c < 2; c++ {
+			v := int16(math.Round(max(-1, min(1, smp[c])) * math.MaxInt16))
+			binary.LittleEndian.PutUint16(frame[2*c:], uint16(v))
+		}
+		if _, err := s.w.Write(frame[:]); err != nil {
+			return err
+		}
+	}
+	s.data += uint32(4 * len(buf))
+	return nil
+}
+
+// writeHeader writes the RIFF header at the start of the file, with the sizes
+// of what has been recorded so far.
+func (s *WAVSink) writeHeader() error {
+	const channels, bits = 2, 16
+	h := struct {
+		Riff          [4]byte
+		RiffSize      uint32
+		Wave, Fmt     [4]byte
+		FmtSize       uint32
+		Format        uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+		Data          [4]byte
+		DataSize      uint32
+	}{
+		Riff: [4]byte{'R', 'I', 'F', 'F'}, RiffSize: 36 + s.data,
+		Wave: [4]byte{'W', 'A', 'V', 'E'}, Fmt: [4]byte{'f', 'm', 't', ' '},
+		FmtSize: 16, Format: 1, Channels: channels, SampleRate: uint32(s.sr),
+		ByteRate:   uint32(s.sr) * channels * bits / 8,
+		BlockAlign: channels * bits / 8, BitsPerSample: bits,
+		Data: [4]byte{'d', 'a', 't', 'a'}, DataSize: s.data,
+	}
+	if _, err := s.f.Seek(0, 0); err != nil {
+		return err
+	}
+	return binary.Write(s.f, binary.LittleEndian, &h)
+}
+
+// Close stops recording, fills in the header and closes the file.
+func (s *WAVSink) Close() error {
+	if s.f == nil {
+		return nil
+	}
+	err := s.clockedSink.Close()
+	if ferr := s.w.Flush(); err == nil {
+		err = ferr
+	}
+	if s.sr != 0 {
+		if herr := s.writeHeader(); err == nil {
+			err = herr
+		}
+	}
+	if cerr := s.f.Close(); err == nil {
+		err = cerr
+	}
+	s.f = nil
+	return err
+}
@@ -0,0 +1,126 @@
+package audio
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gopxl/beep"
+)
+
+func TestNewSink(t *testing.T) {
+	for _, spec := range []string{"", "speaker"} {
+		if sink, err := NewSink(spec); err != nil {
+			t.Errorf("%q: %v", spec, err)
+		} else if _, ok := sink.(*SpeakerSink); !ok {
+			t.Errorf("%q: got %T, %v", spec, sink, err)
+		}
+	}
+	if sink, err := NewSink("null"); err != nil {
+		t.Errorf("null: %v", err)
+	} else if _, ok := sink.(*NullSink); !ok {
+		t.Errorf("null: got %T", sink)
+	}
+	if sink, err := NewSink("wav:" + filepath.Join(t.TempDir(), "out.wav")); err != nil {
+		t.Errorf("wav: %v", err)
+	} else if _, ok := sink.(*WAVSink); !ok {
+		t.Errorf("wav: got %T", sink)
+	}
+	for _, spec := range []string{"wav:", "pulse"} {
+		if _, err := NewSink(spec); err == nil {
+			t.Errorf("%q: want an error", spec)
+		}
+	}
+}
+
+func TestNullSinkPullsInRealTime(t *testing.T) {
+	pulled := 0
+	sink := NewNullSink()
+	sink.Open(beep.StreamerFunc(func(samples [][2]float64) (int, bool) {
+		pulled += len(samples)
+		return len(samples), true
+	}), outputRate)
+	time.Sleep(200 * time.Millisecond)
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if pulled < outputRate.N(100*time.Millisecond) || pulled > outputRate.N(400*time.Millisecond) {
+		t.Errorf("pulled %d samples in 200 ms", pulled)
+	}
+}
+
+func TestWAVSinkRecordsWhatPlays(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.wav")
+	sink, err := NewWAVSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := New("")
+	svc.SetSink(sink)
+	if err := svc.ensureOutput(); err != nil {
+		t.Fatal(err)
+	}
+	svc.SetChannelVolume(ChannelCue, 50)
+	svc.addStream(svc.channels[ChannelCue], constant(0.5), outputRate)
+	time.Sleep(100 * time.Millisecond)
+	if err := svc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data[:4]) != "RIFF" || string(data[8:12]) != "WAVE" || string(data[36:40]) != "data" {
+		t.Fatalf("not a WAV header: %q", data[:44])
+	}
+	if sr := binary.LittleEndian.Uint32(data[24:]); sr != uint32(outputRate) {
+		t.Errorf("sample rate %d", sr)
+	}
+	size := int(binary.LittleEndian.Uint32(data[40:]))
+	if size != len(data)-44 || size < 4*outputRate.N(50*time.Millisecond) {
+		t.Fatalf("data size %d for a %d-byte file", size, len(data))
+	}
+	// 0.5 at half volume, as 16-bit PCM.
+	last := int16(binary.LittleEndian.Uint16(data[len(data)-4:]))
+	if last < 8180 || last > 8200 {
+		t.Errorf("last sample: want ~8192, got %d", last)
+	}
+}
+
+func TestWAVSinkStopsAtTheSizeLimit(t *testing.T) {
+	old := maxWAVData
+	maxWAVData = 4 * 1000
+	t.Cleanup(func() { maxWAVData = old })
+	path := filepath.Join(t.TempDir(), "out.wav")
+	sink, err := NewWAVSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Write by hand rather than through Open's real-time clock.
+	sink.sr = outputRate
+	if err := sink.writeHeader(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := sink.writeSamples(make([][2]float64, 600)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := binary.LittleEndian.Uint32(data[40:]); size != 4000 || len(data) != 44+4000 {
+		t.Errorf("want 4000 bytes of data, got a size of %d in a %d-byte file", size, len(data))
+	}
+	if riff := binary.LittleEndian.Uint32(data[4:]); riff != 36+4000 {
+		t.Errorf("RIFF size %d", riff)
+	}
+}
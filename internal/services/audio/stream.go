@@ -0,0 +1,466 @@
+package audio
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"focusplay/internal/domain"
+
+	"github.com/gopxl/beep"
+)
+
+// Internet radio: an http:// or https:// music path is an MP3 or Ogg Vorbis
+// stream, such as an Icecast or SHOUTcast station. It is decoded on its own
+// goroutine into a buffer the mixer pulls from, so a slow connection delays
+// the music but never the output, and it is reconnected whenever it drops.
+
+const (
+	streamPrebuffer = 500 * time.Millisecond // decoded before a connection starts playing
+	streamAhead     = 4 * time.Second        // most decoded audio held ahead of the output
+	streamRetry     = 500 * time.Millisecond // first pause before reconnecting
+	maxStreamRetry  = 30 * time.Second
+)
+
+// streamIdle is how long a connection may send nothing before it counts as
+// dropped; a var so tests can shorten it.
+var streamIdle = 15 * time.Second
+
+// errBadStream marks a URL that answers but is not a playable stream; it is
+// not retried unless the stream has played before.
+var errBadStream = errors.New("not an audio stream")
+
+// streamTypes maps the content types stations send to decoder names.
+var streamTypes = map[string]string{
+	"audio/mpeg":      "MP3",
+	"audio/mp3":       "MP3",
+	"audio/mpeg3":     "MP3",
+	"audio/ogg":       "Ogg Vorbis",
+	"audio/vorbis":    "Ogg Vorbis",
+	"application/ogg": "Ogg Vorbis",
+}
+
+// isStream reports whether source is an HTTP(S) stream URL.
+func isStream(source string) bool {
+	lower := strings.ToLower(source)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// PlayStream plays an HTTP(S) MP3 or Ogg Vorbis stream as the music until
+// stopped, reconnecting with a growing pause whenever it drops. The station's
+// ICY metadata, when it sends any, names the track.
+func (s *Service) PlayStream(rawURL string) {
+	if err := s.ensureOutput(); err != nil {
+		s.Stop()
+		s.emitState(domain.AudioStopped, "", "Error: "+err.Error())
+		return
+	}
+	r := newRun()
+	fadeIn := s.startMusic(r)
+	station := streamHost(rawURL)
+	s.emitState(domain.AudioPlaying, station, "Radio · connecting")
+	go func() {
+		err := s.runStream(s.channels[ChannelMusic], rawURL, r, fadeIn, func(track, info string) {
+			if !r.stopped() {
+				s.emitState(domain.AudioPlaying, track, info)
+			}
+		})
+		if err != nil && s.stopMusic(r, stopFade) {
+			s.emitState(domain.AudioStopped, station, "Error: "+err.Error())
+		}
+	}()
+}
+
+// runStream plays the stream at rawURL into bus until r stops, fading in
+// over fadeIn and reporting the track name and status line as they change
+// (report may be nil). It returns early only when the first connection finds
+// no playable stream.
+func (s *Service) runStream(bus *channel, rawURL string, r *run, fadeIn time.Duration, report func(track, info string)) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-r.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var mu sync.Mutex
+	station, title := streamHost(rawURL), ""
+	playing := false // titles decoded while connecting wait for the station name
+	show := func(status string) {
+		if report == nil {
+			return
+		}
+		mu.Lock()
+		track, info := station, "Radio"
+		if title != "" {
+			track, info = title, "Radio · "+station
+		}
+		mu.Unlock()
+		if status != "" {
+			info = "Radio · " + status
+		}
+		report(track, info)
+	}
+	onTitle := func(t string) {
+		mu.Lock()
+		changed := t != title && playing
+		title = t
+		mu.Unlock()
+		if changed {
+			show("")
+		}
+	}
+
+	played := false
+	for failed := 0; ; {
+		live, format, name, err := openStream(ctx, rawURL, onTitle)
+		if err == nil {
+			select {
+			case <-live.ready:
+			case <-r.done:
+				live.close()
+				return nil
+			}
+			err = live.failed()
+		}
+		if err == nil {
+			played = true
+			mu.Lock()
+			if name != "" {
+				station = name
+			}
+			playing = true
+			mu.Unlock()
+			show("")
+			started := time.Now()
+			f := s.addFaded(bus, live, format.SampleRate, fadeIn)
+			select {
+			case <-f.ended: // the connection dropped and the buffer has run dry
+			case <-r.done:
+				s.fadeOut(f, r.fade)
+				<-f.ended
+				live.close()
+				return nil
+			}
+			mu.Lock()
+			playing = false
+			mu.Unlock()
+			err = live.failed()
+			fadeIn = stopFade
+			// A connection that drops soon after opening backs off like one that fails.
+			if time.Since(started) > time.Minute {
+				failed = 0
+			}
+		}
+		if live != nil {
+			live.close()
+		}
+		if r.stopped() {
+			return nil
+		}
+		if errors.Is(err, errBadStream) && !played {
+			return err
+		}
+		failed++
+		show("reconnecting: " + err.Error())
+		select {
+		case <-time.After(streamDelay(failed)):
+		case <-r.done:
+			return nil
+		}
+	}
+}
+
+// streamDelay is the pause before reconnection attempt failed: it doubles
+// from streamRetry up to maxStreamRetry.
+func streamDelay(failed int) time.Duration {
+	return min(streamRetry<<min(failed-1, 16), maxStreamRetry)
+}
+
+// streamHost names a station by its host until it sends its own name.
+func streamHost(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return rawURL
+}
+
+// openStream connects to rawURL asking for ICY metadata, picks a decoder from
+// the content type and starts decoding into a liveStream. It returns the
+// station name from the icy-name header, if any. onTitle receives each new
+// StreamTitle as it is decoded.
+func openStream(ctx context.Context, rawURL string, onTitle func(string)) (*liveStream, beep.Format, string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		cancel()
+		return nil, beep.Format{}, "", fmt.Errorf("%w: %v", errBadStream, err)
+	}
+	req.Header.Set("Icy-MetaData", "1")
+	req.Header.Set("User-Agent", "FocusPlay")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, beep.Format{}, "", err
+	}
+	fail := func(err error) (*liveStream, beep.Format, string, error) {
+		resp.Body.Close()
+		cancel()
+		return nil, beep.Format{}, "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("HTTP %s", resp.Status)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+			err = fmt.Errorf("%w: HTTP %s", errBadStream, resp.Status)
+		}
+		return fail(err)
+	}
+
+	// Body → idle watchdog → ICY metadata stripped → buffered for the decoder.
+	idle := time.AfterFunc(streamIdle, cancel)
+	var body io.Reader = &idleReader{r: resp.Body, timer: idle}
+	if n, _ := strconv.Atoi(resp.Header.Get("icy-metaint")); n > 0 {
+		body = &icyReader{r: body, metaint: n, left: n, onTitle: onTitle}
+	}
+	br := bufio.NewReader(body)
+	head, _ := br.Peek(12)
+	d := streamDecoder(resp.Header.Get("Content-Type"), head)
+	if d == nil {
+		idle.Stop()
+		return fail(fmt.Errorf("%w: %s", errBadStream, resp.Header.Get("Content-Type")))
+	}
+	st, format, err := d.decode(readCloser{br, resp.Body})
+	if err != nil {
+		idle.Stop()
+		if ctx.Err() != nil {
+			return fail(err) // dropped while starting: retry
+		}
+		return fail(fmt.Errorf("%w: %s: %v", errBadStream, d.name, err))
+	}
+	live := newLiveStream(st, format.SampleRate, func() {
+		idle.Stop()
+		cancel()
+		st.Close()
+	})
+	return live, format, resp.Header.Get("icy-name"), nil
+}
+
+// streamDecoder picks the decoder for a stream from its content type, then
+// from its first bytes. Stations that send no useful type are taken to be
+// MP3, whose decoder finds the first frame wherever the stream starts.
+func streamDecoder(contentType string, head []byte) *decoder {
+	name := ""
+	if t, _, err := mime.ParseMediaType(contentType); err == nil {
+		name = streamTypes[t]
+		if name == "" && t != "application/octet-stream" {
+			return nil
+		}
+	}
+	if name == "" {
+		if d := decoderFor("", head); d != nil && (d.name == "Ogg Vorbis" || d.name == "MP3") {
+			return d
+		}
+		name = "MP3"
+	}
+	for i := range decoders {
+		if decoders[i].name == name {
+			return &decoders[i]
+		}
+	}
+	return nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// idleReader restarts timer on every read, so the timer only fires once the
+// connection has been silent for its whole duration.
+type idleReader struct {
+	r     io.Reader
+	timer *time.Timer
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.timer.Reset(streamIdle)
+	}
+	return n, err
+}
+
+// icyReader strips the metadata blocks a SHOUTcast/Icecast server inserts
+// every metaint bytes of audio, passing each new StreamTitle to onTitle.
+type icyReader struct {
+	r       io.Reader
+	metaint int
+	left    int // audio bytes before the next metadata block
+	onTitle func(string)
+	title   string
+}
+
+func (r *icyReader) Read(p []byte) (int, error) {
+	if r.left == 0 {
+		if err := r.readMeta(); err != nil {
+			return 0, err
+		}
+		r.left = r.metaint
+	}
+	n, err := r.r.Read(p[:min(len(p), r.left)])
+	r.left -= n
+	return n, err
+}
+
+// readMeta reads one metadata block: a length byte counting 16-byte units,
+// then text such as "StreamTitle='Artist - Song';StreamUrl=”;", NUL-padded.
+func (r *icyReader) readMeta() error {
+	var size [1]byte
+	if _, err := io.ReadFull(r.r, size[:]); err != nil {
+		return err
+	}
+	if size[0] == 0 {
+		return nil
+	}
+	meta := make([]byte, 16*int(size[0]))
+	if _, err := io.ReadFull(r.r, meta); err != nil {
+		return err
+	}
+	if title, ok := icyField(strings.TrimRight(string(meta), "\x00"), "StreamTitle"); ok && title != r.title {
+		r.title = title
+		if r.onTitle != nil {
+			r.onTitle(title)
+		}
+	}
+	return nil
+}
+
+// icyField returns the value of key in ICY metadata. Values are quoted with
+// ' and may themselves contain quotes, so a value runs to the next "';".
+func icyField(meta, key string) (string, bool) {
+	_, rest, ok := strings.Cut(meta, key+"='")
+	if !ok {
+		return "", false
+	}
+	value, _, found := strings.Cut(rest, "';")
+	if !found {
+		value = strings.TrimSuffix(rest, "'")
+	}
+	return strings.TrimSpace(value), true
+}
+
+// liveStream buffers a decoded network stream for the mixer. A goroutine
+// decodes up to streamAhead in advance; Stream plays silence while the buffer
+// is empty and the connection is alive, and ends once it has dropped and the
+// buffer has run dry.
+type liveStream struct {
+	mu     sync.Mutex
+	buf    [][2]float64
+	ended  bool          // the decoder has stopped; buf holds the rest
+	err    error         // why it stopped
+	ready  chan struct{} // closed once prebuffered, or ended before that
+	room   chan struct{} // signalled when Stream takes samples
+	quit   chan struct{} // closed by close
+	stop   func()        // closes the connection
+	closed sync.Once
+}
+
+func newLiveStream(src beep.Streamer, sr beep.SampleRate, stop func()) *liveStream {
+	l := &liveStream{
+		ready: make(chan struct{}),
+		room:  make(chan struct{}, 1),
+		quit:  make(chan struct{}),
+		stop:  stop,
+	}
+	go l.fill(src, sr.N(streamPrebuffer), sr.N(streamAhead))
+	return l
+}
+
+func (l *liveStream) fill(src beep.Streamer, prebuffer, ahead int) {
+	chunk := make([][2]float64, 1024)
+	started := false
+	for {
+		n, ok := src.Stream(chunk)
+		l.mu.Lock()
+		l.buf = append(l.buf, chunk[:n]...)
+		if !ok {
+			l.ended = true
+			if l.err = src.Err(); l.err == nil {
+				l.err = io.ErrUnexpectedEOF
+			}
+		}
+		full := len(l.buf) >= ahead
+		if !started && (len(l.buf) >= prebuffer || l.ended) {
+			started = true
+			close(l.ready)
+		}
+		ended := l.ended
+		l.mu.Unlock()
+		if ended {
+			return
+		}
+		for full {
+			select {
+			case <-l.room:
+			case <-l.quit:
+				return
+			}
+			l.mu.Lock()
+			full = len(l.buf) >= ahead
+			l.mu.Unlock()
+		}
+	}
+}
+
+// failed returns why the stream ended when it did so before playing anything.
+func (l *liveStream) failed() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ended && len(l.buf) == 0 {
+		return l.err
+	}
+	return nil
+}
+
+func (l *liveStream) Stream(samples [][2]float64) (int, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ended && len(l.buf) == 0 {
+		return 0, false
+	}
+	n := copy(samples, l.buf)
+	l.buf = l.buf[n:]
+	if !l.ended {
+		clear(samples[n:]) // waiting on the network
+		n = len(samples)
+	}
+	select {
+	case l.room <- struct{}{}:
+	default:
+	}
+	return n, true
+}
+
+func (l *liveStream) Err() error {
+	return nil
+}
+
+// close hangs up and stops the decoder goroutine.
+func (l *liveStream) close() {
+	l.closed.Do(func() {
+		close(l.quit)
+		l.stop()
+	})
+}
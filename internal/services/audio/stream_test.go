@@ -0,0 +1,231 @@
+package audio
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"focusplay/internal/domain"
+
+	"github.com/gopxl/beep"
+)
+
+// silentMP3 returns n silent MPEG-1 Layer III frames (128 kbit/s, 44.1 kHz),
+// about 26 ms each.
+func silentMP3(n int) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	return bytes.Repeat(frame, n)
+}
+
+// withICY interleaves audio with a metadata block every metaint bytes, each
+// announcing the next of titles ("" sends an empty block).
+func withICY(audio []byte, metaint int, titles ...string) []byte {
+	var out bytes.Buffer
+	for i := 0; len(audio) > 0; i++ {
+		n := min(metaint, len(audio))
+		out.Write(audio[:n])
+		audio = audio[n:]
+		if n < metaint {
+			break
+		}
+		title := ""
+		if len(titles) > 0 {
+			title = titles[min(i, len(titles)-1)]
+		}
+		if title == "" {
+			out.WriteByte(0)
+			continue
+		}
+		meta := []byte("StreamTitle='" + title + "';")
+		size := (len(meta) + 15) / 16
+		out.WriteByte(byte(size))
+		out.Write(append(meta, make([]byte, 16*size-len(meta))...))
+	}
+	return out.Bytes()
+}
+
+func TestICYReaderStripsMetadata(t *testing.T) {
+	audio := []byte("0123456789abcdefghij")
+	var titles []string
+	r := &icyReader{
+		r:       bytes.NewReader(withICY(audio, 4, "", "Artist - One", "Artist - One", "It's - Two")),
+		metaint: 4, left: 4,
+		onTitle: func(s string) { titles = append(titles, s) },
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(audio) {
+		t.Errorf("audio: got %q", got)
+	}
+	if strings.Join(titles, "|") != "Artist - One|It's - Two" {
+		t.Errorf("titles: got %q", titles)
+	}
+}
+
+func TestStreamDecoderFromContentType(t *testing.T) {
+	cases := map[string]string{
+		"audio/mpeg":               "MP3",
+		"application/ogg":          "Ogg Vorbis",
+		"audio/ogg; codecs=vorbis": "Ogg Vorbis",
+		"":                         "MP3",
+		"application/octet-stream": "MP3",
+	}
+	for ct, want := range cases {
+		if d := streamDecoder(ct, nil); d == nil || d.name != want {
+			t.Errorf("%q: want %s, got %v", ct, want, d)
+		}
+	}
+	if d := streamDecoder("", []byte("OggS\x00")); d == nil || d.name != "Ogg Vorbis" {
+		t.Errorf("untyped Ogg: got %v", d)
+	}
+	for _, ct := range []string{"audio/aacp", "text/html"} {
+		if d := streamDecoder(ct, nil); d != nil {
+			t.Errorf("%q: want no decoder, got %s", ct, d.name)
+		}
+	}
+}
+
+// radioServer serves about a second of silent MP3 per connection with ICY
+// metadata, then either hangs up or, with hang set, goes quiet.
+func radioServer(t *testing.T, hang bool) (*httptest.Server, *atomic.Int32) {
+	var conns atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conns.Add(1)
+		if r.Header.Get("Icy-MetaData") != "1" {
+			t.Error("the request should ask for ICY metadata")
+		}
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Header().Set("icy-name", "Test FM")
+		w.Header().Set("icy-metaint", "8192")
+		w.Write(withICY(silentMP3(40), 8192, "Artist - Song"))
+		w.(http.Flusher).Flush()
+		if hang {
+			<-r.Context().Done()
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &conns
+}
+
+func TestPlayStreamNamesTrackAndReconnects(t *testing.T) {
+	srv, conns := radioServer(t, false)
+	svc := New("")
+	svc.SetSink(NewNullSink())
+	defer svc.Close()
+
+	svc.PlaySource(srv.URL+"/lofi.mp3", false, domain.ScanOptions{})
+	st := waitState(t, svc, func(st domain.AudioStatePayload) bool { return st.TrackName == "Artist - Song" })
+	if st.State != domain.AudioPlaying || st.TrackInfo != "Radio · Test FM" {
+		t.Errorf("got %+v", st)
+	}
+	waitState(t, svc, func(domain.AudioStatePayload) bool { return conns.Load() >= 2 })
+
+	svc.Stop()
+	waitStreams(t, svc, 0)
+	n := conns.Load()
+	time.Sleep(streamRetry + 200*time.Millisecond)
+	if conns.Load() != n {
+		t.Error("a stopped stream should not reconnect")
+	}
+}
+
+func TestSilentConnectionCountsAsDrop(t *testing.T) {
+	old := streamIdle
+	streamIdle = 200 * time.Millisecond
+	t.Cleanup(func() { streamIdle = old })
+	srv, conns := radioServer(t, true)
+	svc := New("")
+	svc.SetSink(NewNullSink())
+	defer svc.Close()
+
+	svc.PlayStream(srv.URL)
+	waitState(t, svc, func(domain.AudioStatePayload) bool { return conns.Load() >= 2 })
+	svc.Stop()
+}
+
+func TestPlayStreamStopsOnNonAudio(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "<html>Now playing</html>")
+	}))
+	defer srv.Close()
+	svc := newTestOutput()
+
+	for _, path := range []string{"/page", "/missing"} {
+		svc.PlayStream(srv.URL + path)
+		st := waitState(t, svc, func(st domain.AudioStatePayload) bool { return st.State == domain.AudioStopped })
+		if !strings.Contains(st.TrackInfo, errBadStream.Error()) {
+			t.Errorf("%s: got %q", path, st.TrackInfo)
+		}
+	}
+}
+
+func TestLateStreamErrorLeavesTheNextSourcePlaying(t *testing.T) {
+	answer := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-answer
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+	svc := newTestOutput()
+
+	svc.PlayStream(srv.URL)
+	svc.PlayNoise(NoiseWhite)
+	log := &eventLog{}
+	svc.SetEmitter(log)
+	close(answer)
+	time.Sleep(200 * time.Millisecond)
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if st := svc.GetState(); st.State != domain.AudioPlaying || len(log.events["audioStateChanged"]) != 0 {
+		t.Errorf("the failed stream stopped the noise: %+v after %v", st, log.events["audioStateChanged"])
+	}
+}
+
+func TestLiveStreamFillsGapsWithSilence(t *testing.T) {
+	feed := make(chan float64)
+	src := beep.StreamerFunc(func(samples [][2]float64) (int, bool) {
+		v, ok := <-feed
+		if !ok {
+			return 0, false
+		}
+		samples[0] = [2]float64{v, v}
+		return 1, true
+	})
+	l := newLiveStream(src, outputRate, func() {})
+	defer l.close()
+	feed <- 0.5
+	feed <- 0.25
+
+	buf := make([][2]float64, 4)
+	deadline := time.Now().Add(time.Second)
+	for n := 0; n < 2 && time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		l.mu.Lock()
+		n = len(l.buf)
+		l.mu.Unlock()
+	}
+	if n, ok := l.Stream(buf); n != 4 || !ok || buf[0][0] != 0.5 || buf[1][0] != 0.25 || buf[3][0] != 0 {
+		t.Errorf("while connected: got %d, %v, %v", n, ok, buf)
+	}
+	close(feed)
+	<-l.ready
+	for time.Now().Before(deadline) && l.failed() == nil {
+		time.Sleep(time.Millisecond)
+	}
+	if n, ok := l.Stream(buf); n != 0 || ok {
+		t.Errorf("after the drop: got %d, %v", n, ok)
+	}
+}
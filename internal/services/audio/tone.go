@@ -0,0 +1,188 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gopxl/beep"
+)
+
+// TonePrefix marks a generated tone in a profile music path, written as
+// "tone:<mode>:<carrier Hz>:<beat Hz>[:<waveform>]", e.g. "tone:binaural:200:10:sine".
+const TonePrefix = "tone:"
+
+// Tone modes.
+const (
+	ToneBinaural   = "binaural"   // left at carrier − beat/2, right at carrier + beat/2
+	ToneIsochronic = "isochronic" // one carrier pulsed on and off beat times a second
+)
+
+// Tone waveforms.
+const (
+	WaveSine     = "sine"
+	WaveTriangle = "triangle"
+	WaveSquare   = "square"
+)
+
+// ToneSpec describes a binaural beat or isochronic tone.
+type ToneSpec struct {
+	Mode    string  `json:"mode"`
+	Carrier float64 `json:"carrier"` // Hz, 20–1500
+	Beat    float64 `json:"beat"`    // Hz, 0.5–40
+	Wave    string  `json:"wave"`
+}
+
+// TonePreset is a named tone offered in the profile editor.
+type TonePreset struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+}
+
+// TonePresets lists ready-made tones for the common brainwave bands.
+func TonePresets() []TonePreset {
+	return []TonePreset{
+		{Name: "Binaural · theta 6 Hz", Source: "tone:binaural:180:6:sine"},
+		{Name: "Binaural · alpha 10 Hz", Source: "tone:binaural:200:10:sine"},
+		{Name: "Binaural · beta 18 Hz", Source: "tone:binaural:220:18:sine"},
+		{Name: "Binaural · gamma 40 Hz", Source: "tone:binaural:250:40:sine"},
+		{Name: "Isochronic · alpha 10 Hz", Source: "tone:isochronic:220:10:sine"},
+		{Name: "Isochronic · beta 16 Hz", Source: "tone:isochronic:300:16:triangle"},
+	}
+}
+
+// ParseTone parses a tone source with or without TonePrefix. The waveform
+// defaults to sine.
+func ParseTone(source string) (ToneSpec, error) {
+	parts := strings.Split(strings.TrimPrefix(source, TonePrefix), ":")
+	if len(parts) < 3 || len(parts) > 4 {
+		return ToneSpec{}, fmt.Errorf("invalid tone %q (want mode:carrier:beat[:wave])", source)
+	}
+	spec := ToneSpec{Mode: parts[0], Wave: WaveSine}
+	var err error
+	if spec.Carrier, err = strconv.ParseFloat(parts[1], 64); err != nil {
+		return ToneSpec{}, fmt.Errorf("invalid tone carrier %q", parts[1])
+	}
+	if spec.Beat, err = strconv.ParseFloat(parts[2], 64); err != nil {
+		return ToneSpec{}, fmt.Errorf("invalid tone beat %q", parts[2])
+	}
+	if len(parts) == 4 {
+		spec.Wave = parts[3]
+	}
+	return spec, spec.validate()
+}
+
+// String formats the spec as a music path, the inverse of ParseTone.
+func (t ToneSpec) String() string {
+	return fmt.Sprintf("%s%s:%s:%s:%s", TonePrefix, t.Mode,
+		strconv.FormatFloat(t.Carrier, 'f', -1, 64), strconv.FormatFloat(t.Beat, 'f', -1, 64), t.Wave)
+}
+
+func (t ToneSpec) validate() error {
+	switch t.Mode {
+	case ToneBinaural, ToneIsochronic:
+	default:
+		return fmt.Errorf("unknown tone mode %q", t.Mode)
+	}
+	switch t.Wave {
+	case WaveSine, WaveTriangle, WaveSquare:
+	default:
+		return fmt.Errorf("unknown waveform %q", t.Wave)
+	}
+	if t.Carrier < 20 || t.Carrier > 1500 {
+		return fmt.Errorf("tone carrier %g Hz out of range (20–1500)", t.Carrier)
+	}
+	if t.Beat < 0.5 || t.Beat > 40 {
+		return fmt.Errorf("tone beat %g Hz out of range (0.5–40)", t.Beat)
+	}
+	// Each ear hears carrier ± beat/2, and both must stay audible.
+	if t.Mode == ToneBinaural && t.Carrier-t.Beat/2 < 20 {
+		return fmt.Errorf("tone carrier %g Hz too low for a %g Hz binaural beat (needs at least %g)", t.Carrier, t.Beat, 20+t.Beat/2)
+	}
+	return nil
+}
+
+// label is the track name shown while the tone plays.
+func (t ToneSpec) label() string {
+	mode := "Binaural beat"
+	if t.Mode == ToneIsochronic {
+		mode = "Isochronic tone"
+	}
+	return fmt.Sprintf("%s · %g Hz", mode, t.Beat)
+}
+
+// tone is an endless stereo tone generator. Phases are kept in cycles (0–1)
+// and accumulated per sample, so long sessions never drift or lose precision.
+type tone struct {
+	spec  ToneSpec
+	sr    float64
+	freq  [2]float64 // per-channel carrier
+	phase [2]float64
+	gate  float64 // isochronic pulse phase
+	ramp  float64 // pulse edge length in cycles of the beat
+}
+
+// newTone returns a generator for spec at sample rate sr.
+func newTone(spec ToneSpec, sr beep.SampleRate) (beep.Streamer, error) {
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+	t := &tone{spec: spec, sr: float64(sr), freq: [2]float64{spec.Carrier, spec.Carrier}}
+	if spec.Mode == ToneBinaural {
+		t.freq = [2]float64{spec.Carrier - spec.Beat/2, spec.Carrier + spec.Beat/2}
+	}
+	// 5 ms edges avoid clicks without blurring the pulse, capped for fast beats.
+	t.ramp = math.Min(0.005*spec.Beat, 0.1)
+	return t, nil
+}
+
+func (t *tone) Stream(samples [][2]float64) (int, bool) {
+	for i := range samples {
+		env := 0.25
+		if t.spec.Mode == ToneIsochronic {
+			env *= pulse(t.gate, t.ramp)
+			t.gate = advancePhase(t.gate, t.spec.Beat/t.sr)
+		}
+		for c := 0; c < 2; c++ {
+			samples[i][c] = env * waveform(t.spec.Wave, t.phase[c])
+			t.phase[c] = advancePhase(t.phase[c], t.freq[c]/t.sr)
+		}
+	}
+	return len(samples), true
+}
+
+func (t *tone) Err() error { return nil }
+
+func advancePhase(p, step float64) float64 {
+	p += step
+	return p - math.Floor(p)
+}
+
+// waveform evaluates one cycle of wave at phase p (0–1), in −1…1.
+func waveform(wave string, p float64) float64 {
+	switch wave {
+	case WaveTriangle:
+		return 1 - 4*math.Abs(p-0.5)
+	case WaveSquare:
+		if p < 0.5 {
+			return 1
+		}
+		return -1
+	}
+	return math.Sin(2 * math.Pi * p)
+}
+
+// pulse is the isochronic envelope: on for the first half of each beat cycle,
+// with raised-cosine edges of ramp cycles.
+func pulse(p, ramp float64) float64 {
+	switch {
+	case p >= 0.5:
+		return 0
+	case p < ramp:
+		return 0.5 - 0.5*math.Cos(math.Pi*p/ramp)
+	case p > 0.5-ramp:
+		return 0.5 - 0.5*math.Cos(math.Pi*(0.5-p)/ramp)
+	}
+	return 1
+}
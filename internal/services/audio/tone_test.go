@@ -0,0 +1,133 @@
+package audio
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// peakHz returns the strongest frequency in one channel of buf, refined by
+// parabolic interpolation between FFT bins. len(buf) must be a power of two.
+func peakHz(buf [][2]float64, c int) float64 {
+	n := len(buf)
+	x := make([]complex128, n)
+	for i := range buf {
+		w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n-1))
+		x[i] = complex(buf[i][c]*w, 0)
+	}
+	fft(x)
+	mag := func(k int) float64 { return math.Log(math.Hypot(real(x[k]), imag(x[k])) + 1e-12) }
+	best := 1
+	for k := 2; k < n/2; k++ {
+		if mag(k) > mag(best) {
+			best = k
+		}
+	}
+	a, b, g := mag(best-1), mag(best), mag(best+1)
+	offset := 0.5 * (a - g) / (a - 2*b + g)
+	return (float64(best) + offset) * float64(outputRate) / float64(n)
+}
+
+func render(t *testing.T, source string, n int) [][2]float64 {
+	t.Helper()
+	spec, err := ParseTone(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen, err := newTone(spec, outputRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([][2]float64, n)
+	gen.Stream(buf)
+	return buf
+}
+
+func TestBinauralChannelFrequencies(t *testing.T) {
+	for _, wave := range []string{WaveSine, WaveTriangle, WaveSquare} {
+		buf := render(t, "tone:binaural:200:10:"+wave, 1<<16)
+		left, right := peakHz(buf, 0), peakHz(buf, 1)
+		if math.Abs(left-195) > 0.5 || math.Abs(right-205) > 0.5 {
+			t.Errorf("%s: want L 195 Hz / R 205 Hz, got %.2f / %.2f", wave, left, right)
+		}
+		if beat := right - left; math.Abs(beat-10) > 0.5 {
+			t.Errorf("%s: want a 10 Hz L/R difference, got %.2f", wave, beat)
+		}
+	}
+}
+
+func TestIsochronicPulsesAtBeatRate(t *testing.T) {
+	buf := render(t, "tone:isochronic:300:8", 1<<17)
+	if l, r := peakHz(buf, 0), peakHz(buf, 1); math.Abs(l-300) > 0.5 || math.Abs(r-300) > 0.5 {
+		t.Errorf("want a 300 Hz carrier on both sides, got %.2f / %.2f", l, r)
+	}
+
+	// Count pulse onsets over two seconds from 1 ms block RMS.
+	block := outputRate.N(time.Millisecond)
+	secs := 2
+	onsets, on := 0, false
+	for start := 0; start+block <= outputRate.N(time.Duration(secs)*time.Second); start += block {
+		var sum float64
+		for _, s := range buf[start : start+block] {
+			sum += s[0] * s[0]
+		}
+		loud := math.Sqrt(sum/float64(block)) > 0.05
+		if loud && !on {
+			onsets++
+		}
+		on = loud
+	}
+	if onsets != 8*secs {
+		t.Errorf("want %d pulses in %d s, got %d", 8*secs, secs, onsets)
+	}
+}
+
+func TestToneStaysInRange(t *testing.T) {
+	for _, src := range []string{"tone:binaural:120:4:square", "tone:isochronic:440:40:triangle"} {
+		for _, s := range render(t, src, 44100) {
+			if math.Abs(s[0]) > 1 || math.Abs(s[1]) > 1 {
+				t.Fatalf("%s: sample %v out of range", src, s)
+			}
+		}
+	}
+}
+
+func TestParseTone(t *testing.T) {
+	spec, err := ParseTone("tone:binaural:200:10.5")
+	if err != nil {
+		t.Fatalf("ParseTone: %v", err)
+	}
+	if spec.Wave != WaveSine || spec.Carrier != 200 || spec.Beat != 10.5 {
+		t.Errorf("unexpected spec %+v", spec)
+	}
+	if got := spec.String(); got != "tone:binaural:200:10.5:sine" {
+		t.Errorf("String: got %q", got)
+	}
+
+	if _, err := ParseTone("tone:isochronic:20:40"); err != nil {
+		t.Errorf("an isochronic tone plays the carrier alone: %v", err)
+	}
+
+	for _, bad := range []string{
+		"tone:binaural:200",        // missing beat
+		"tone:stereo:200:10",       // unknown mode
+		"tone:binaural:200:10:saw", // unknown waveform
+		"tone:binaural:5:10",       // carrier too low
+		"tone:binaural:200:90",     // beat too fast
+		"tone:binaural:20:40",      // left ear at 0 Hz
+		"tone:binaural:30:30",      // left ear below 20 Hz
+		"tone:binaural:abc:10",
+	} {
+		if _, err := ParseTone(bad); err == nil {
+			t.Errorf("ParseTone(%q): expected error", bad)
+		}
+	}
+}
+
+func TestPresetsParse(t *testing.T) {
+	for _, p := range TonePresets() {
+		if _, err := ParseTone(p.Source); err != nil {
+			t.Errorf("preset %q: %v", p.Name, err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package audio
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"focusplay/internal/domain"
+)
+
+// waitTrack waits for the playing track to become name.
+func waitTrack(t *testing.T, svc *Service, name string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for svc.GetState().TrackName != name {
+		if time.Now().After(deadline) {
+			t.Fatalf("want track %q, still on %q", name, svc.GetState().TrackName)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// waitStreams waits for the music channel to hold n streams.
+func waitStreams(t *testing.T, svc *Service, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for musicStreams(svc) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("want %d music streams, have %d", n, musicStreams(svc))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPauseKeepsPosition(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.wav", "b.wav"} {
+		writeWAV(t, filepath.Join(dir, name)) // 0.1 s → 4410 output samples
+	}
+	svc := newTestOutput()
+	svc.PlayFolder(dir, false, domain.ScanOptions{})
+	waitStreams(t, svc, 1)
+	pull(svc, 2000)
+
+	svc.PauseAudio()
+	if st := svc.GetState(); st.State != domain.AudioPaused || st.TrackName != "a.wav" {
+		t.Fatalf("want paused on a.wav, got %+v", st)
+	}
+	if got := rms(svc, 10000); got != 0 {
+		t.Errorf("paused music should be silent, got rms %.4f", got)
+	}
+
+	if !svc.ResumeAudio() {
+		t.Fatal("ResumeAudio: want true after a pause")
+	}
+	if got := rms(svc, 1000); got == 0 {
+		t.Error("resumed music should be audible")
+	}
+	// a.wav continues from 3000 samples, so it ends well before another 4410 are pulled.
+	pull(svc, 2000)
+	waitTrack(t, svc, "b.wav")
+	if st := svc.GetState(); st.State != domain.AudioPlaying {
+		t.Errorf("want playing after resume, got %q", st.State)
+	}
+	if svc.ResumeAudio() {
+		t.Error("ResumeAudio: want false when nothing is paused")
+	}
+}
+
+func TestPlayWhilePausedStartsFresh(t *testing.T) {
+	svc := newTestOutput()
+	svc.PlayNoise(NoiseWhite)
+	svc.PauseAudio()
+	svc.PlayNoise(NoisePink)
+	time.Sleep(20 * time.Millisecond) // let the old run drop its stream
+
+	if st := svc.GetState(); st.State != domain.AudioPlaying {
+		t.Fatalf("want playing, got %q", st.State)
+	}
+	pull(svc, 1024)
+	if n := musicStreams(svc); n != 1 {
+		t.Errorf("the paused source should be dropped at once, got %d streams", n)
+	}
+}
+
+func TestNextAndPreviousTrack(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.wav", "b.wav", "c.wav"} {
+		writeWAV(t, filepath.Join(dir, name))
+	}
+	svc := newTestOutput()
+	svc.PlayFolder(dir, false, domain.ScanOptions{})
+	waitTrack(t, svc, "a.wav")
+
+	for _, step := range []struct {
+		move func() error
+		want string
+	}{
+		{svc.NextTrack, "b.wav"},
+		{svc.PreviousTrack, "a.wav"},
+		{svc.PreviousTrack, "c.wav"}, // wraps to the end
+		{svc.NextTrack, "a.wav"},
+	} {
+		if err := step.move(); err != nil {
+			t.Fatal(err)
+		}
+		waitTrack(t, svc, step.want)
+	}
+}
+
+func TestSkipWithoutTracks(t *testing.T) {
+	svc := newTestOutput()
+	if err := svc.NextTrack(); err == nil {
+		t.Error("NextTrack with nothing playing: expected error")
+	}
+	svc.PlayNoise(NoiseBrown)
+	if err := svc.PreviousTrack(); err == nil {
+		t.Error("PreviousTrack on generated noise: expected error")
+	}
+}
@@ -0,0 +1,381 @@
+// Package mpris publishes the music player on the D-Bus session bus as an
+// MPRIS media player (org.mpris.MediaPlayer2), so desktop media keys, panel
+// widgets and tools such as playerctl can control FocusPlay's audio.
+package mpris
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"runtime"
+	"slices"
+	"sync"
+
+	"focusplay/internal/domain"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+const (
+	busName     = "org.mpris.MediaPlayer2.focusplay"
+	objectPath  = dbus.ObjectPath("/org/mpris/MediaPlayer2")
+	rootIface   = "org.mpris.MediaPlayer2"
+	playerIface = "org.mpris.MediaPlayer2.Player"
+	noTrack     = dbus.ObjectPath("/org/mpris/MediaPlayer2/TrackList/NoTrack")
+	trackPrefix = "/org/focusplay/track/"
+)
+
+// Player is the audio MPRIS controls — satisfied by *audio.Service.
+type Player interface {
+	GetState() domain.AudioStatePayload
+	PauseAudio()
+	ResumeAudio() bool
+	Stop()
+	NextTrack() error
+	PreviousTrack() error
+	SetVolume(v int)
+}
+
+// Service is the MPRIS object for a Player. It mirrors the audio state passed
+// to Update and turns MPRIS calls into Player calls.
+type Service struct {
+	mu     sync.Mutex
+	player Player
+	play   func() // starts fresh music when Play finds nothing paused
+	conn   *dbus.Conn
+	props  map[string]any // the player properties as last published
+	track  string         // the current track's path or name, to tell tracks apart
+	trackN int            // numbers the track IDs
+}
+
+// rootProps are the properties of org.mpris.MediaPlayer2, which never change.
+var rootProps = map[string]any{
+	"CanQuit":             false,
+	"CanRaise":            false,
+	"HasTrackList":        false,
+	"Identity":            "FocusPlay",
+	"DesktopEntry":        "focusplay",
+	"SupportedUriSchemes": []string{},
+	"SupportedMimeTypes":  []string{},
+}
+
+// fixedProps are the player properties that do not follow the audio state.
+var fixedProps = map[string]any{
+	"Rate":        1.0,
+	"MinimumRate": 1.0,
+	"MaximumRate": 1.0,
+	"CanSeek":     false,
+	"CanControl":  true,
+}
+
+// New creates a Service for player. play starts the music when Play is
+// pressed with nothing paused, e.g. the music of the current timer segment.
+func New(player Player, play func()) *Service {
+	return &Service{player: player, play: play}
+}
+
+// Start connects to the session bus and publishes the player. It does
+// nothing on Windows and macOS, which have no session bus.
+func (s *Service) Start() error {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return nil
+	}
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return err
+	}
+	if err := s.export(conn); err != nil {
+		conn.Close()
+		return err
+	}
+	return nil
+}
+
+// Close leaves the session bus.
+func (s *Service) Close() error {
+	s.mu.Lock()
+	conn := s.conn
+	s.conn = nil
+	s.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+	return conn.Close()
+}
+
+// export publishes the object on conn and claims the bus name, or a
+// per-process one when another FocusPlay already holds it.
+func (s *Service) export(conn *dbus.Conn) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.props = s.playerProps(s.player.GetState())
+	exports := []struct {
+		v     any
+		iface string
+	}{
+		{root{}, rootIface},
+		{player{s}, playerIface},
+		{properties{s}, "org.freedesktop.DBus.Properties"},
+	}
+	for _, e := range exports {
+		if err := conn.ExportWithMap(e.v, playerMethods, objectPath, e.iface); err != nil {
+			return err
+		}
+	}
+	node := &introspect.Node{
+		Name: string(objectPath),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			{Name: rootIface, Methods: introspect.Methods(root{}), Properties: introspection(rootProps)},
+			{Name: playerIface, Methods: playerIntrospection(), Properties: slices.Concat(introspection(fixedProps), introspection(s.props), introspection(map[string]any{"Position": int64(0)}))},
+		},
+	}
+	if err := conn.Export(introspect.NewIntrospectable(node), objectPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		return err
+	}
+	for _, name := range []string{busName, fmt.Sprintf("%s.instance%d", busName, os.Getpid())} {
+		reply, err := conn.RequestName(name, dbus.NameFlagDoNotQueue)
+		if err != nil {
+			return err
+		}
+		if reply == dbus.RequestNameReplyPrimaryOwner {
+			s.conn = conn
+			return nil
+		}
+	}
+	return errors.New("mpris: bus name taken")
+}
+
+// introspection describes props; only Volume is writable.
+func introspection(props map[string]any) []introspect.Property {
+	var out []introspect.Property
+	for name, v := range props {
+		access := "read"
+		if name == "Volume" {
+			access = "readwrite"
+		}
+		out = append(out, introspect.Property{Name: name, Type: dbus.SignatureOf(v).String(), Access: access})
+	}
+	return out
+}
+
+// Update publishes st — playback status, track metadata and volume — and
+// signals what changed. Call it on every "audioStateChanged".
+func (s *Service) Update(st domain.AudioStatePayload) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return
+	}
+	changed := map[string]dbus.Variant{}
+	for name, v := range s.playerProps(st) {
+		if reflect.DeepEqual(s.props[name], v) {
+			continue
+		}
+		s.props[name] = v
+		changed[name] = dbus.MakeVariant(v)
+	}
+	if len(changed) > 0 {
+		s.conn.Emit(objectPath, "org.freedesktop.DBus.Properties.PropertiesChanged", playerIface, changed, []string{})
+	}
+}
+
+// playerProps returns the properties that follow the audio state, other than
+// Position (see livePosition). Call with s.mu held.
+func (s *Service) playerProps(st domain.AudioStatePayload) map[string]any {
+	status := "Stopped"
+	switch st.State {
+	case domain.AudioPlaying:
+		status = "Playing"
+	case domain.AudioPaused:
+		status = "Paused"
+	}
+	tracks := st.Meta != nil && status != "Stopped" // files, folders and playlists
+	return map[string]any{
+		"PlaybackStatus": status,
+		"Metadata":       s.metadata(st, status),
+		"Volume":         float64(st.Volume) / 100,
+		"CanGoNext":      tracks,
+		"CanGoPrevious":  tracks,
+		"CanPlay":        true,
+		"CanPause":       status != "Stopped",
+	}
+}
+
+// livePosition is the Position property: how far into the track the player
+// is, in microseconds. It moves all the time without being signalled, so it is
+// read from the player whenever a client asks rather than kept in s.props.
+func livePosition(p Player) int64 {
+	if st := p.GetState(); st.Meta != nil {
+		return st.Meta.ElapsedMs * 1000
+	}
+	return 0
+}
+
+// metadata describes the playing track in MPRIS terms. Each new track gets a
+// new track ID. Call with s.mu held.
+func (s *Service) metadata(st domain.AudioStatePayload, status string) map[string]dbus.Variant {
+	if status == "Stopped" {
+		return map[string]dbus.Variant{"mpris:trackid": dbus.MakeVariant(noTrack)}
+	}
+	meta := domain.TrackMeta{}
+	if st.Meta != nil {
+		meta = *st.Meta
+	}
+	key := st.TrackName
+	if meta.Path != "" {
+		key = meta.Path
+	}
+	if key != s.track {
+		s.track = key
+		s.trackN++
+	}
+	title := meta.Title
+	if title == "" {
+		title = st.TrackName
+	}
+	m := map[string]dbus.Variant{
+		"mpris:trackid": dbus.MakeVariant(dbus.ObjectPath(fmt.Sprintf("%s%d", trackPrefix, s.trackN))),
+		"xesam:title":   dbus.MakeVariant(title),
+	}
+	if meta.Artist != "" {
+		m["xesam:artist"] = dbus.MakeVariant([]string{meta.Artist})
+	}
+	if meta.Album != "" {
+		m["xesam:album"] = dbus.MakeVariant(meta.Album)
+	}
+	if meta.DurationMs > 0 {
+		m["mpris:length"] = dbus.MakeVariant(meta.DurationMs * 1000)
+	}
+	return m
+}
+
+// root implements org.mpris.MediaPlayer2. FocusPlay can be neither raised
+// nor quit from outside, as CanRaise and CanQuit say.
+type root struct{}
+
+func (root) Raise() *dbus.Error { return nil }
+func (root) Quit() *dbus.Error  { return nil }
+
+// player implements org.mpris.MediaPlayer2.Player.
+type player struct{ s *Service }
+
+// playerMethods renames the Go methods whose D-Bus names would clash with
+// io.Seeker.
+var playerMethods = map[string]string{"SeekBy": "Seek"}
+
+// playerIntrospection describes the player's methods under their D-Bus names.
+func playerIntrospection() []introspect.Method {
+	methods := introspect.Methods(player{})
+	for i, m := range methods {
+		if name, ok := playerMethods[m.Name]; ok {
+			methods[i].Name = name
+		}
+	}
+	return methods
+}
+
+func (p player) Play() *dbus.Error {
+	if p.s.player.GetState().State != domain.AudioPlaying && !p.s.player.ResumeAudio() && p.s.play != nil {
+		p.s.play()
+	}
+	return nil
+}
+
+func (p player) Pause() *dbus.Error {
+	p.s.player.PauseAudio()
+	return nil
+}
+
+func (p player) PlayPause() *dbus.Error {
+	if p.s.player.GetState().State == domain.AudioPlaying {
+		return p.Pause()
+	}
+	return p.Play()
+}
+
+func (p player) Stop() *dbus.Error {
+	p.s.player.Stop()
+	return nil
+}
+
+// Next and Previous do nothing when the audio has no tracks, as MPRIS asks.
+func (p player) Next() *dbus.Error {
+	p.s.player.NextTrack()
+	return nil
+}
+
+func (p player) Previous() *dbus.Error {
+	p.s.player.PreviousTrack()
+	return nil
+}
+
+// SeekBy and SetPosition do nothing: CanSeek is false.
+func (p player) SeekBy(offset int64) *dbus.Error                          { return nil }
+func (p player) SetPosition(track dbus.ObjectPath, pos int64) *dbus.Error { return nil }
+
+func (p player) OpenUri(uri string) *dbus.Error {
+	return dbus.MakeFailedError(errors.New("opening URIs is not supported"))
+}
+
+// properties implements org.freedesktop.DBus.Properties for both interfaces.
+type properties struct{ s *Service }
+
+func (p properties) Get(iface, name string) (dbus.Variant, *dbus.Error) {
+	all, err := p.GetAll(iface)
+	if err != nil {
+		return dbus.Variant{}, err
+	}
+	v, ok := all[name]
+	if !ok {
+		return dbus.Variant{}, prop.ErrPropNotFound
+	}
+	return v, nil
+}
+
+func (p properties) GetAll(iface string) (map[string]dbus.Variant, *dbus.Error) {
+	var position int64
+	if iface == playerIface {
+		position = livePosition(p.s.player) // before s.mu: it takes the player's lock
+	}
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	var sets []map[string]any
+	switch iface {
+	case rootIface:
+		sets = []map[string]any{rootProps}
+	case playerIface:
+		sets = []map[string]any{fixedProps, p.s.props, {"Position": position}}
+	default:
+		return nil, prop.ErrIfaceNotFound
+	}
+	all := map[string]dbus.Variant{}
+	for _, props := range sets {
+		for name, v := range props {
+			all[name] = dbus.MakeVariant(v)
+		}
+	}
+	return all, nil
+}
+
+// Set changes the volume, the one writable property. The new volume comes
+// back through Update.
+func (p properties) Set(iface, name string, v dbus.Variant) *dbus.Error {
+	if _, err := p.Get(iface, name); err != nil {
+		return err
+	}
+	if iface != playerIface || name != "Volume" {
+		return prop.ErrReadOnly
+	}
+	vol, ok := v.Value().(float64)
+	if !ok {
+		return prop.ErrInvalidArg
+	}
+	p.s.player.SetVolume(int(math.Round(max(0, min(vol, 1)) * 100)))
+	return nil
+}
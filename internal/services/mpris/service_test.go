@@ -0,0 +1,260 @@
+package mpris
+
+import (
+	"bufio"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"focusplay/internal/domain"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// sessionBus starts a private session bus for the test and points
+// DBUS_SESSION_BUS_ADDRESS at it, skipping the test without dbus-daemon.
+func sessionBus(t *testing.T) string {
+	t.Helper()
+	bin, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not found")
+	}
+	cmd := exec.Command(bin, "--session", "--nofork", "--print-address=1")
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skip("dbus-daemon:", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	addr, err := bufio.NewReader(out).ReadString('\n')
+	if err != nil {
+		t.Fatal("dbus-daemon address:", err)
+	}
+	addr = strings.TrimSpace(addr)
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", addr)
+	return addr
+}
+
+// fakePlayer records the calls it gets.
+type fakePlayer struct {
+	mu     sync.Mutex
+	state  domain.AudioStatePayload
+	paused bool // what ResumeAudio reports
+	calls  []string
+	volume chan int
+}
+
+func (p *fakePlayer) record(call string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, call)
+}
+
+func (p *fakePlayer) GetState() domain.AudioStatePayload {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state
+}
+
+func (p *fakePlayer) PauseAudio()          { p.record("pause") }
+func (p *fakePlayer) Stop()                { p.record("stop") }
+func (p *fakePlayer) NextTrack() error     { p.record("next"); return nil }
+func (p *fakePlayer) PreviousTrack() error { p.record("previous"); return nil }
+func (p *fakePlayer) SetVolume(v int)      { p.volume <- v }
+
+func (p *fakePlayer) ResumeAudio() bool {
+	p.record("resume")
+	return p.paused
+}
+
+func (p *fakePlayer) Calls() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return strings.Join(p.calls, " ")
+}
+
+// start publishes a Service for p on the test bus and connects a client.
+func start(t *testing.T, p *fakePlayer) (*Service, dbus.BusObject, *dbus.Conn) {
+	t.Helper()
+	addr := sessionBus(t)
+	svc := New(p, func() { p.record("play") })
+	if err := svc.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { svc.Close() })
+	conn, err := dbus.Connect(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return svc, conn.Object(busName, objectPath), conn
+}
+
+func call(t *testing.T, obj dbus.BusObject, method string) {
+	t.Helper()
+	if err := obj.Call(playerIface+"."+method, 0).Err; err != nil {
+		t.Fatalf("%s: %v", method, err)
+	}
+}
+
+func TestControlsReachThePlayer(t *testing.T) {
+	p := &fakePlayer{state: domain.AudioStatePayload{State: domain.AudioPlaying}, volume: make(chan int, 1)}
+	_, obj, _ := start(t, p)
+
+	call(t, obj, "PlayPause")
+	call(t, obj, "Next")
+	call(t, obj, "Previous")
+	p.mu.Lock()
+	p.state.State = domain.AudioStopped
+	p.mu.Unlock()
+	call(t, obj, "PlayPause") // nothing to resume: starts fresh music
+	p.mu.Lock()
+	p.paused = true
+	p.mu.Unlock()
+	call(t, obj, "Play")
+	call(t, obj, "Stop")
+	if got, want := p.Calls(), "pause next previous resume play resume stop"; got != want {
+		t.Errorf("calls: got %q, want %q", got, want)
+	}
+
+	if err := obj.SetProperty(playerIface+".Volume", dbus.MakeVariant(0.25)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case v := <-p.volume:
+		if v != 25 {
+			t.Errorf("volume: got %d, want 25", v)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("setting Volume should set the player's volume")
+	}
+
+	if err := obj.Call(playerIface+".OpenUri", 0, "file:///tmp/a.mp3").Err; err == nil {
+		t.Error("OpenUri should fail")
+	}
+}
+
+// changes merges the PropertiesChanged signals that arrive until the bus
+// has been quiet for a moment.
+func changes(signals <-chan *dbus.Signal) map[string]dbus.Variant {
+	changed := map[string]dbus.Variant{}
+	for {
+		select {
+		case sig := <-signals:
+			if sig.Name == "org.freedesktop.DBus.Properties.PropertiesChanged" {
+				for name, v := range sig.Body[1].(map[string]dbus.Variant) {
+					changed[name] = v
+				}
+			}
+		case <-time.After(200 * time.Millisecond):
+			return changed
+		}
+	}
+}
+
+func TestUpdatePublishesState(t *testing.T) {
+	p := &fakePlayer{volume: make(chan int, 1)}
+	svc, obj, conn := start(t, p)
+	if v, err := obj.GetProperty(rootIface + ".Identity"); err != nil || v.Value() != "FocusPlay" {
+		t.Errorf("Identity: got %v, %v", v, err)
+	}
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(objectPath),
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+	); err != nil {
+		t.Fatal(err)
+	}
+	signals := make(chan *dbus.Signal, 10)
+	conn.Signal(signals)
+
+	st := domain.AudioStatePayload{
+		State:     domain.AudioPlaying,
+		TrackName: "one.mp3",
+		Volume:    40,
+		Meta:      &domain.TrackMeta{Path: "/music/one.mp3", Title: "One", Artist: "Artist", DurationMs: 90000},
+	}
+	svc.Update(st)
+	if changed := changes(signals); changed["PlaybackStatus"].Value() != "Playing" || changed["Volume"].Value() != 0.4 {
+		t.Errorf("PropertiesChanged: got %v", changed)
+	}
+
+	v, err := obj.GetProperty(playerIface + ".Metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := v.Value().(map[string]dbus.Variant)
+	if meta["xesam:title"].Value() != "One" || meta["mpris:length"].Value() != int64(90000000) {
+		t.Errorf("Metadata: got %v", meta)
+	}
+	if artists, _ := meta["xesam:artist"].Value().([]string); len(artists) != 1 || artists[0] != "Artist" {
+		t.Errorf("artist: got %v", meta["xesam:artist"])
+	}
+	first := meta["mpris:trackid"].Value()
+
+	// The same state again changes nothing.
+	svc.Update(st)
+	if changed := changes(signals); len(changed) != 0 {
+		t.Errorf("an unchanged state should not signal, got %v", changed)
+	}
+
+	st.Meta = &domain.TrackMeta{Path: "/music/two.mp3", Title: "Two"}
+	svc.Update(st)
+	v, _ = obj.GetProperty(playerIface + ".Metadata")
+	meta = v.Value().(map[string]dbus.Variant)
+	if id := meta["mpris:trackid"].Value(); id == first {
+		t.Errorf("a new track should get a new track ID, still %v", id)
+	}
+	if _, ok := meta["xesam:artist"]; ok {
+		t.Errorf("the next track has no artist, got %v", meta)
+	}
+
+	svc.Update(domain.AudioStatePayload{State: domain.AudioStopped})
+	if v, _ := obj.GetProperty(playerIface + ".CanGoNext"); v.Value() != false {
+		t.Error("a stopped player cannot go to the next track")
+	}
+}
+
+func TestPositionIsReadLive(t *testing.T) {
+	p := &fakePlayer{volume: make(chan int, 1)}
+	svc, obj, _ := start(t, p)
+	svc.Update(domain.AudioStatePayload{State: domain.AudioPlaying, Meta: &domain.TrackMeta{Path: "/music/one.mp3", ElapsedMs: 1000}})
+
+	// The track plays on without a new state.
+	p.mu.Lock()
+	p.state = domain.AudioStatePayload{State: domain.AudioPlaying, Meta: &domain.TrackMeta{Path: "/music/one.mp3", ElapsedMs: 2500}}
+	p.mu.Unlock()
+	if v, err := obj.GetProperty(playerIface + ".Position"); err != nil || v.Value() != int64(2500000) {
+		t.Errorf("Position: got %v, %v; want the player's position now", v, err)
+	}
+}
+
+func TestSecondInstanceTakesItsOwnName(t *testing.T) {
+	p := &fakePlayer{volume: make(chan int, 1)}
+	_, _, conn := start(t, p)
+
+	second := New(p, nil)
+	if err := second.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+	var names []string
+	if err := conn.BusObject().Call("org.freedesktop.DBus.ListNames", 0).Store(&names); err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, name := range names {
+		if strings.HasPrefix(name, busName) {
+			n++
+		}
+	}
+	if n != 2 || !strings.Contains(strings.Join(names, " "), busName+".instance") {
+		t.Errorf("names: got %v", names)
+	}
+}
@@ -15,6 +15,7 @@ import (
 type Service struct {
 	mu       sync.Mutex
 	filePath string
+	playback func() *domain.PlaybackState
 }
 
 // New creates a Service that stores session state under dataDir.
@@ -40,11 +41,21 @@ func (s *Service) Load() *domain.SessionState {
 	return &state
 }
 
-// Save writes state.json with the current Unix timestamp.
+// SetPlaybackSource registers where Save reads the music position from.
+func (s *Service) SetPlaybackSource(fn func() *domain.PlaybackState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.playback = fn
+}
+
+// Save writes state.json with the current Unix timestamp and music position.
 func (s *Service) Save(state domain.SessionState) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	state.SavedAt = time.Now().Unix()
+	if s.playback != nil {
+		state.Playback = s.playback()
+	}
 	return storage.Save(s.filePath, state)
 }
 
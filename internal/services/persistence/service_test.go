@@ -88,3 +88,17 @@ func TestClearDeletesFile(t *testing.T) {
 		t.Error("state.json not deleted after Clear")
 	}
 }
+
+func TestSaveIncludesPlayback(t *testing.T) {
+	svc := &Service{filePath: filepath.Join(t.TempDir(), "state.json")}
+	svc.SetPlaybackSource(func() *domain.PlaybackState {
+		return &domain.PlaybackState{Source: "/music", Tracks: []string{"/music/a.mp3"}, PositionMs: 42000}
+	})
+	if err := svc.Save(domain.SessionState{ProfileID: "pomodoro", TotalSec: 1500}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	got := svc.Load()
+	if got == nil || got.Playback == nil || got.Playback.PositionMs != 42000 {
+		t.Fatalf("want the playback position saved with the session, got %+v", got)
+	}
+}
@@ -0,0 +1,287 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"focusplay/internal/domain"
+	"focusplay/internal/infra/events"
+	"focusplay/internal/infra/storage"
+)
+
+// grace is how late an occurrence may still be started (e.g. the app was busy or
+// just launched). Older missed occurrences are skipped rather than started late.
+const grace = 2 * time.Minute
+
+// Starter runs a profile's session — satisfied by *timer.Service. Active
+// reports a session that is running or paused, which a scheduled start must
+// not replace.
+type Starter interface {
+	StartProfile(p domain.Profile) error
+	Active() bool
+}
+
+// Service starts profiles at scheduled clock times and warns before they begin.
+// Schedules live in schedules.json so they survive restarts.
+type Service struct {
+	mu        sync.Mutex
+	schedules []domain.Schedule
+	filePath  string
+	emitter   events.Emitter
+	starter   Starter
+	lookup    func(id string) *domain.Profile
+	now       func() time.Time
+	cancel    context.CancelFunc
+	done      chan struct{} // closed when the background loop exits
+}
+
+// New creates a Service that stores schedules under dataDir. lookup resolves
+// profile IDs at fire time so edits to a profile apply to its next run.
+func New(dataDir string, starter Starter, lookup func(id string) *domain.Profile) *Service {
+	ss := &Service{
+		filePath: filepath.Join(dataDir, "schedules.json"),
+		emitter:  events.Noop{},
+		starter:  starter,
+		lookup:   lookup,
+		now:      time.Now,
+	}
+	_ = storage.Load(ss.filePath, &ss.schedules)
+	return ss
+}
+
+// SetEmitter replaces the emitter (called from App.startup with the live Wails emitter).
+func (ss *Service) SetEmitter(e events.Emitter) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.emitter = e
+}
+
+// Start launches the background loop that checks schedules every 15 seconds.
+func (ss *Service) Start() {
+	ss.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	ss.mu.Lock()
+	ss.cancel, ss.done = cancel, done
+	ss.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(15 * time.Second)
+		defer ticker.Stop()
+		ss.check()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ss.check()
+			}
+		}
+	}()
+}
+
+// Stop halts the background loop and waits for a check in progress, so no
+// scheduled start fires once it returns.
+func (ss *Service) Stop() {
+	ss.mu.Lock()
+	cancel, done := ss.cancel, ss.done
+	ss.cancel, ss.done = nil, nil
+	ss.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// List returns all schedules.
+func (ss *Service) List() []domain.Schedule {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	out := make([]domain.Schedule, len(ss.schedules))
+	copy(out, ss.schedules)
+	return out
+}
+
+// Save validates and upserts a schedule, then writes schedules.json.
+// A schedule without an ID is assigned one.
+func (ss *Service) Save(sch domain.Schedule) (domain.Schedule, error) {
+	if _, _, err := parseClock(sch.Time); err != nil {
+		return sch, err
+	}
+	for _, d := range sch.Weekdays {
+		if d < 0 || d > 6 {
+			return sch, fmt.Errorf("invalid weekday %d (want 0-6)", d)
+		}
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if sch.ID == "" {
+		sch.ID = fmt.Sprintf("s%d", ss.now().UnixNano())
+	}
+	for i, existing := range ss.schedules {
+		if existing.ID == sch.ID {
+			// Keep run bookkeeping so an edit does not re-fire today's occurrence.
+			sch.LastRun = existing.LastRun
+			sch.LastWarned = existing.LastWarned
+			ss.schedules[i] = sch
+			return sch, ss.saveUnlocked()
+		}
+	}
+	ss.schedules = append(ss.schedules, sch)
+	return sch, ss.saveUnlocked()
+}
+
+// Delete removes a schedule by ID and persists the change.
+func (ss *Service) Delete(id string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	filtered := ss.schedules[:0]
+	for _, sch := range ss.schedules {
+		if sch.ID != id {
+			filtered = append(filtered, sch)
+		}
+	}
+	ss.schedules = filtered
+	return ss.saveUnlocked()
+}
+
+// NextRun returns the next time sch fires strictly after t (zero if it never does).
+func NextRun(sch domain.Schedule, t time.Time) time.Time {
+	h, m, err := parseClock(sch.Time)
+	if err != nil {
+		return time.Time{}
+	}
+	for day := 0; day <= 7; day++ {
+		d := t.AddDate(0, 0, day)
+		occ := time.Date(d.Year(), d.Month(), d.Day(), h, m, 0, 0, t.Location())
+		if occ.After(t) && onWeekday(sch, occ.Weekday()) {
+			return occ
+		}
+	}
+	return time.Time{}
+}
+
+// ── internal ──────────────────────────────────────────────────────────────────
+
+// check starts any occurrence that is due and emits upcoming-session warnings.
+func (ss *Service) check() {
+	now := ss.now()
+	type fire struct {
+		sch     domain.Schedule
+		profile domain.Profile
+	}
+	var starts []fire
+	var warnings []map[string]interface{}
+
+	ss.mu.Lock()
+	dirty := false
+	for i := range ss.schedules {
+		sch := &ss.schedules[i]
+		if !sch.Enabled {
+			continue
+		}
+		if occ := lastRun(*sch, now); !occ.IsZero() && now.Sub(occ) < grace && sch.LastRun < occ.Unix() {
+			sch.LastRun = occ.Unix()
+			dirty = true
+			if p := ss.lookup(sch.ProfileID); p != nil {
+				starts = append(starts, fire{sch: *sch, profile: *p})
+			}
+		}
+		if sch.WarnMinutes <= 0 {
+			continue
+		}
+		next := NextRun(*sch, now)
+		if next.IsZero() || sch.LastWarned >= next.Unix() {
+			continue
+		}
+		if left := next.Sub(now); left <= time.Duration(sch.WarnMinutes)*time.Minute {
+			sch.LastWarned = next.Unix()
+			dirty = true
+			payload := map[string]interface{}{
+				"scheduleId":  sch.ID,
+				"profileId":   sch.ProfileID,
+				"startsAt":    next.Unix(),
+				"minutesLeft": int((left + time.Minute - 1) / time.Minute),
+			}
+			if p := ss.lookup(sch.ProfileID); p != nil {
+				payload["profileName"] = p.Name
+			}
+			warnings = append(warnings, payload)
+		}
+	}
+	if dirty {
+		_ = ss.saveUnlocked()
+	}
+	emitter := ss.emitter
+	ss.mu.Unlock()
+
+	for _, w := range warnings {
+		emitter.Emit("scheduleUpcoming", w)
+	}
+	for _, f := range starts {
+		payload := map[string]interface{}{
+			"scheduleId": f.sch.ID,
+			"profileId":  f.profile.ID,
+		}
+		// A session in progress is the user's work: leave it alone and say so.
+		if ss.starter.Active() {
+			payload["profileName"] = f.profile.Name
+			payload["reason"] = "a session is already in progress"
+			emitter.Emit("scheduleSkipped", payload)
+			continue
+		}
+		if err := ss.starter.StartProfile(f.profile); err != nil {
+			payload["profileName"] = f.profile.Name
+			payload["reason"] = err.Error()
+			emitter.Emit("scheduleSkipped", payload)
+			continue
+		}
+		emitter.Emit("scheduleStarted", payload)
+	}
+}
+
+// lastRun returns the most recent occurrence of sch at or before t (zero if none this week).
+func lastRun(sch domain.Schedule, t time.Time) time.Time {
+	h, m, err := parseClock(sch.Time)
+	if err != nil {
+		return time.Time{}
+	}
+	for day := 0; day <= 7; day++ {
+		d := t.AddDate(0, 0, -day)
+		occ := time.Date(d.Year(), d.Month(), d.Day(), h, m, 0, 0, t.Location())
+		if !occ.After(t) && onWeekday(sch, occ.Weekday()) {
+			return occ
+		}
+	}
+	return time.Time{}
+}
+
+func onWeekday(sch domain.Schedule, wd time.Weekday) bool {
+	if len(sch.Weekdays) == 0 {
+		return true
+	}
+	for _, d := range sch.Weekdays {
+		if time.Weekday(d) == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock parses "HH:MM" (24 h).
+func parseClock(s string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time %q (want HH:MM)", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+func (ss *Service) saveUnlocked() error {
+	return storage.Save(ss.filePath, ss.schedules)
+}
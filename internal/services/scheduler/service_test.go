@@ -0,0 +1,202 @@
+package scheduler
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"focusplay/internal/domain"
+)
+
+type fakeStarter struct {
+	mu      sync.Mutex
+	started []string
+	active  bool
+}
+
+func (f *fakeStarter) Active() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.active
+}
+
+func (f *fakeStarter) StartProfile(p domain.Profile) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.started = append(f.started, p.ID)
+	return nil
+}
+
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) Emit(event string, _ any) { r.events = append(r.events, event) }
+
+func lookup(id string) *domain.Profile {
+	return &domain.Profile{ID: id, Name: "Team focus", DurationSec: 1500}
+}
+
+// at returns a fixed local time on Wednesday 2026-03-04.
+func at(hour, minute int) time.Time {
+	return time.Date(2026, 3, 4, hour, minute, 0, 0, time.Local)
+}
+
+func newTestScheduler(t *testing.T, dir string) (*Service, *fakeStarter, *recorder) {
+	t.Helper()
+	starter := &fakeStarter{}
+	rec := &recorder{}
+	ss := New(dir, starter, lookup)
+	ss.SetEmitter(rec)
+	return ss, starter, rec
+}
+
+func TestSaveRejectsBadTime(t *testing.T) {
+	ss, _, _ := newTestScheduler(t, t.TempDir())
+	if _, err := ss.Save(domain.Schedule{ProfileID: "p", Time: "9:3x"}); err == nil {
+		t.Error("expected error for malformed time")
+	}
+	if _, err := ss.Save(domain.Schedule{ProfileID: "p", Time: "09:30", Weekdays: []int{7}}); err == nil {
+		t.Error("expected error for weekday 7")
+	}
+}
+
+func TestSaveAssignsID(t *testing.T) {
+	ss, _, _ := newTestScheduler(t, t.TempDir())
+	sch, err := ss.Save(domain.Schedule{ProfileID: "p", Time: "09:30", Enabled: true})
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if sch.ID == "" || len(ss.List()) != 1 {
+		t.Errorf("want one schedule with an ID, got %+v", ss.List())
+	}
+}
+
+func TestNextRunHonoursWeekdays(t *testing.T) {
+	weekdays := domain.Schedule{Time: "09:30", Weekdays: []int{1, 2, 3, 4, 5}}
+	// Wednesday 10:00 → Thursday 09:30
+	if got := NextRun(weekdays, at(10, 0)); !got.Equal(time.Date(2026, 3, 5, 9, 30, 0, 0, time.Local)) {
+		t.Errorf("NextRun after Wed 10:00: got %v", got)
+	}
+	// Friday 10:00 → Monday 09:30
+	fri := time.Date(2026, 3, 6, 10, 0, 0, 0, time.Local)
+	if got := NextRun(weekdays, fri); !got.Equal(time.Date(2026, 3, 9, 9, 30, 0, 0, time.Local)) {
+		t.Errorf("NextRun after Fri 10:00: got %v", got)
+	}
+}
+
+func TestCheckStartsDueScheduleOnce(t *testing.T) {
+	ss, starter, rec := newTestScheduler(t, t.TempDir())
+	ss.Save(domain.Schedule{ID: "am", ProfileID: "team", Time: "09:30", Enabled: true})
+
+	ss.now = func() time.Time { return at(9, 29) }
+	ss.check()
+	if len(starter.started) != 0 {
+		t.Fatal("must not start before the scheduled time")
+	}
+
+	ss.now = func() time.Time { return at(9, 30).Add(20 * time.Second) }
+	ss.check()
+	ss.check()
+	if len(starter.started) != 1 || starter.started[0] != "team" {
+		t.Fatalf("want exactly one start of 'team', got %v", starter.started)
+	}
+	if rec.events[len(rec.events)-1] != "scheduleStarted" {
+		t.Errorf("want scheduleStarted event, got %v", rec.events)
+	}
+}
+
+func TestCheckLeavesActiveSessionAlone(t *testing.T) {
+	ss, starter, rec := newTestScheduler(t, t.TempDir())
+	ss.Save(domain.Schedule{ID: "am", ProfileID: "team", Time: "09:30", Enabled: true})
+	starter.active = true
+
+	ss.now = func() time.Time { return at(9, 30) }
+	ss.check()
+	if len(starter.started) != 0 {
+		t.Fatalf("must not replace a session in progress, started %v", starter.started)
+	}
+	if rec.events[len(rec.events)-1] != "scheduleSkipped" {
+		t.Errorf("want scheduleSkipped event, got %v", rec.events)
+	}
+
+	// The occurrence is spent: it does not start once the session ends.
+	starter.active = false
+	ss.check()
+	if len(starter.started) != 0 {
+		t.Errorf("a skipped occurrence must not start later, started %v", starter.started)
+	}
+}
+
+func TestStopWaitsForTheLoop(t *testing.T) {
+	ss, _, _ := newTestScheduler(t, t.TempDir())
+	ss.Start()
+	ss.Stop()
+	ss.Stop() // idempotent
+	if ss.cancel != nil || ss.done != nil {
+		t.Error("Stop should clear the loop")
+	}
+}
+
+func TestCheckSkipsStaleOccurrence(t *testing.T) {
+	ss, starter, _ := newTestScheduler(t, t.TempDir())
+	ss.Save(domain.Schedule{ID: "am", ProfileID: "team", Time: "09:30", Enabled: true})
+
+	ss.now = func() time.Time { return at(11, 0) }
+	ss.check()
+	if len(starter.started) != 0 {
+		t.Error("an occurrence missed by hours must not start late")
+	}
+}
+
+func TestCheckSkipsDisabledAndOtherDays(t *testing.T) {
+	ss, starter, _ := newTestScheduler(t, t.TempDir())
+	ss.Save(domain.Schedule{ID: "off", ProfileID: "p", Time: "09:30", Enabled: false})
+	ss.Save(domain.Schedule{ID: "mon", ProfileID: "p", Time: "09:30", Weekdays: []int{1}, Enabled: true})
+
+	ss.now = func() time.Time { return at(9, 30) } // Wednesday
+	ss.check()
+	if len(starter.started) != 0 {
+		t.Errorf("nothing should start, got %v", starter.started)
+	}
+}
+
+func TestUpcomingWarningOnce(t *testing.T) {
+	ss, _, rec := newTestScheduler(t, t.TempDir())
+	ss.Save(domain.Schedule{ID: "pm", ProfileID: "team", Time: "14:00", WarnMinutes: 5, Enabled: true})
+
+	ss.now = func() time.Time { return at(13, 50) }
+	ss.check()
+	if len(rec.events) != 0 {
+		t.Fatalf("no warning expected 10 minutes out, got %v", rec.events)
+	}
+
+	ss.now = func() time.Time { return at(13, 56) }
+	ss.check()
+	ss.check()
+	if len(rec.events) != 1 || rec.events[0] != "scheduleUpcoming" {
+		t.Errorf("want a single scheduleUpcoming, got %v", rec.events)
+	}
+}
+
+func TestRunSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	s1, starter1, _ := newTestScheduler(t, dir)
+	s1.Save(domain.Schedule{ID: "am", ProfileID: "team", Time: "09:30", Enabled: true})
+	s1.now = func() time.Time { return at(9, 30) }
+	s1.check()
+	if len(starter1.started) != 1 {
+		t.Fatal("first instance should start the session")
+	}
+
+	// A restart within the grace window must not start the same occurrence again.
+	s2, starter2, _ := newTestScheduler(t, dir)
+	if len(s2.List()) != 1 {
+		t.Fatal("schedules not reloaded from disk")
+	}
+	s2.now = func() time.Time { return at(9, 31) }
+	s2.check()
+	if len(starter2.started) != 0 {
+		t.Error("restarted instance re-fired an occurrence that already ran")
+	}
+}
@@ -40,6 +40,18 @@ func (ss *Service) Save(s domain.Settings) error {
 	return storage.Save(ss.filePath, s)
 }
 
+// SetDefaultVolume keeps v as the master volume for the next launch, writing
+// settings.json only when it changes.
+func (ss *Service) SetDefaultVolume(v int) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.current.DefaultVolume == v {
+		return nil
+	}
+	ss.current.DefaultVolume = v
+	return storage.Save(ss.filePath, ss.current)
+}
+
 func (ss *Service) load() {
 	_ = storage.Load(ss.filePath, &ss.current)
 }
@@ -1,6 +1,7 @@
 package settings
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -81,3 +82,40 @@ func TestSaveVolumeBoundaries(t *testing.T) {
 		}
 	}
 }
+
+func TestDefaultChimeSettings(t *testing.T) {
+	got := newSvc(t).Get()
+	if !got.PlaySoundOnComplete {
+		t.Error("PlaySoundOnComplete should default to true")
+	}
+	if got.CompletionChime == "" || got.ChimeVolume <= 0 {
+		t.Errorf("want a default chime and volume, got %q at %d", got.CompletionChime, got.ChimeVolume)
+	}
+}
+
+func TestOldSettingsFileGetsDefaultFades(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "settings.json"), []byte(`{"defaultVolume": 40}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(dir).Get()
+	if s.DefaultVolume != 40 {
+		t.Errorf("saved volume lost: got %d", s.DefaultVolume)
+	}
+	if s.FadeInSec != 3 || s.CrossfadeSec != 4 || s.FadeOutSec != 5 {
+		t.Errorf("missing fade fields should keep defaults, got %d/%d/%d", s.FadeInSec, s.CrossfadeSec, s.FadeOutSec)
+	}
+}
+
+func TestSetDefaultVolumePersists(t *testing.T) {
+	svc := newSvc(t)
+	svc.current.Theme = "light"
+	if err := svc.SetDefaultVolume(35); err != nil {
+		t.Fatal(err)
+	}
+	loaded := &Service{filePath: svc.filePath, current: domain.DefaultSettings()}
+	loaded.load()
+	if got := loaded.Get(); got.DefaultVolume != 35 || got.Theme != "light" {
+		t.Errorf("want volume 35 and the other settings kept, got %+v", got)
+	}
+}
@@ -0,0 +1,98 @@
+package soundscape
+
+import (
+	"path/filepath"
+	"sync"
+
+	"focusplay/internal/domain"
+	"focusplay/internal/infra/storage"
+)
+
+// Service handles loading and saving soundscapes from AppData/soundscapes.json.
+type Service struct {
+	mu          sync.RWMutex
+	soundscapes []domain.Soundscape
+	filePath    string
+}
+
+// New creates a Service that stores soundscapes under dataDir.
+func New(dataDir string) *Service {
+	return &Service{
+		filePath: filepath.Join(dataDir, "soundscapes.json"),
+	}
+}
+
+// Load reads soundscapes.json and caches the result. Returns defaults on first run.
+func (s *Service) Load() []domain.Soundscape {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := storage.Load(s.filePath, &s.soundscapes); err != nil || len(s.soundscapes) == 0 {
+		s.soundscapes = defaultSoundscapes()
+		_ = s.saveUnlocked()
+	}
+	return s.soundscapes
+}
+
+// Save upserts a soundscape in the cache and writes soundscapes.json.
+func (s *Service) Save(sc domain.Soundscape) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range sc.Layers {
+		sc.Layers[i].Volume = max(0, min(sc.Layers[i].Volume, 100))
+	}
+	for i, existing := range s.soundscapes {
+		if existing.ID == sc.ID {
+			s.soundscapes[i] = sc
+			return s.saveUnlocked()
+		}
+	}
+	s.soundscapes = append(s.soundscapes, sc)
+	return s.saveUnlocked()
+}
+
+// GetByID returns a soundscape from the in-memory cache only.
+func (s *Service) GetByID(id string) *domain.Soundscape {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, sc := range s.soundscapes {
+		if sc.ID == id {
+			cp := sc
+			cp.Layers = append([]domain.SoundLayer(nil), sc.Layers...)
+			return &cp
+		}
+	}
+	return nil
+}
+
+// Delete removes a soundscape by ID and persists the change.
+func (s *Service) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	filtered := s.soundscapes[:0]
+	for _, sc := range s.soundscapes {
+		if sc.ID != id {
+			filtered = append(filtered, sc)
+		}
+	}
+	s.soundscapes = filtered
+	return s.saveUnlocked()
+}
+
+func (s *Service) saveUnlocked() error {
+	return storage.Save(s.filePath, s.soundscapes)
+}
+
+// defaultSoundscapes only use generated noise so they work without any files.
+func defaultSoundscapes() []domain.Soundscape {
+	return []domain.Soundscape{
+		{ID: "rainy-night", Name: "Rainy night", Layers: []domain.SoundLayer{
+			{Source: "noise:rain", Volume: 80, Loop: true},
+			{Source: "noise:brown", Volume: 35, Loop: true},
+		}},
+		{ID: "stormy-sea", Name: "Stormy sea", Layers: []domain.SoundLayer{
+			{Source: "noise:ocean", Volume: 75, Loop: true},
+			{Source: "noise:rain", Volume: 45, Loop: true},
+		}},
+	}
+}
@@ -0,0 +1,73 @@
+package soundscape
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"focusplay/internal/domain"
+)
+
+func TestLoadReturnsDefaults(t *testing.T) {
+	svc := New(t.TempDir())
+
+	scapes := svc.Load()
+	if len(scapes) == 0 {
+		t.Fatal("Expected default soundscapes")
+	}
+	for _, sc := range scapes {
+		if len(sc.Layers) < 2 {
+			t.Errorf("default %q should layer several sources, got %d", sc.ID, len(sc.Layers))
+		}
+	}
+	if _, err := os.Stat(svc.filePath); err != nil {
+		t.Errorf("soundscapes.json not created: %v", err)
+	}
+}
+
+func TestSaveClampsLayerVolume(t *testing.T) {
+	svc := New(t.TempDir())
+	svc.Load()
+
+	sc := domain.Soundscape{ID: "cafe", Name: "Café", Layers: []domain.SoundLayer{
+		{Source: "noise:pink", Volume: 140},
+		{Source: "/music/chatter.mp3", Volume: -5, Loop: true},
+	}}
+	if err := svc.Save(sc); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	got := svc.GetByID("cafe")
+	if got == nil {
+		t.Fatal("Soundscape not found after save")
+	}
+	if got.Layers[0].Volume != 100 || got.Layers[1].Volume != 0 {
+		t.Errorf("volumes not clamped: %+v", got.Layers)
+	}
+}
+
+func TestGetByIDReturnsCopy(t *testing.T) {
+	svc := New(t.TempDir())
+	svc.Load()
+
+	got := svc.GetByID("rainy-night")
+	got.Layers[0].Volume = 1
+	if svc.GetByID("rainy-night").Layers[0].Volume == 1 {
+		t.Error("editing the returned soundscape must not change the cache")
+	}
+}
+
+func TestDeletePersists(t *testing.T) {
+	dir := t.TempDir()
+	svc := New(dir)
+	svc.Load()
+	if err := svc.Delete("stormy-sea"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	reloaded := &Service{filePath: filepath.Join(dir, "soundscapes.json")}
+	for _, sc := range reloaded.Load() {
+		if sc.ID == "stormy-sea" {
+			t.Error("deleted soundscape came back after reload")
+		}
+	}
+}
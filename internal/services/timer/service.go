@@ -2,6 +2,7 @@ package timer
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"time"
 
@@ -11,16 +12,31 @@ import (
 )
 
 // Service manages the countdown timer and emits Wails events via an Emitter.
+// A session is an ordered list of segments; totalSec/remainSec describe the current one.
 type Service struct {
 	mu          sync.Mutex
 	persistence *persistence.Service
 	emitter     events.Emitter
+	tick        time.Duration // one timer "second"; shortened in tests
 
 	totalSec  int
 	remainSec int
 	profileID string
+	segments  []domain.Segment
+	segIdx    int
 	running   bool
 	cancel    context.CancelFunc
+	gen       uint64 // bumped whenever the tick loop is replaced, so stale loops bail out
+
+	pauseCount  int
+	pausedSec   int       // completed pauses only; see pausedSecLocked
+	pausedAt    time.Time // zero unless paused
+	pauseTimer  *time.Timer
+	maxPauseSec int
+	pauseAction string
+
+	warningSec []int // thresholds (seconds left in a segment) that fire timerWarning
+	warningCue bool
 }
 
 // New creates a Service. Call SetEmitter after the Wails context is available.
@@ -28,6 +44,7 @@ func New(ps *persistence.Service) *Service {
 	return &Service{
 		persistence: ps,
 		emitter:     events.Noop{},
+		tick:        time.Second,
 	}
 }
 
@@ -40,79 +57,388 @@ func (s *Service) SetEmitter(e events.Emitter) {
 
 // Start begins a new countdown for durationSec seconds.
 func (s *Service) Start(profileID string, durationSec int) {
+	s.begin(domain.SessionState{
+		ProfileID:    profileID,
+		RemainingSec: durationSec,
+		Segments:     []domain.Segment{singleSegment(durationSec)},
+	})
+}
+
+// ErrEmptyPlan is returned by StartProfile for a profile with nothing to time:
+// every segment has a zero or negative duration.
+var ErrEmptyPlan = errors.New("the profile has no segments with a duration")
+
+// StartProfile begins the profile's full segment sequence from the first segment.
+func (s *Service) StartProfile(p domain.Profile) error {
+	plan := p.Plan()
+	if len(plan) == 0 {
+		return ErrEmptyPlan
+	}
+	s.begin(domain.SessionState{
+		ProfileID:    p.ID,
+		RemainingSec: plan[0].DurationSec,
+		Segments:     plan,
+		MaxPauseSec:  p.MaxPauseSec,
+		PauseAction:  p.PauseAction,
+		WarningSec:   p.WarningSec,
+		WarningCue:   p.WarningCue,
+	})
+	return nil
+}
+
+// Resume restarts the timer from a previously saved state.
+func (s *Service) Resume(state domain.SessionState) {
+	if len(state.Segments) == 0 {
+		state.Segments = []domain.Segment{singleSegment(state.TotalSec)}
+		state.SegmentIndex = 0
+	}
+	if state.SegmentIndex < 0 || state.SegmentIndex >= len(state.Segments) {
+		state.SegmentIndex = 0
+	}
+	s.begin(state)
+}
+
+// Pause stops the tick loop while preserving remaining time. Each pause is
+// counted, and if the profile sets MaxPauseSec the session is abandoned or
+// resumed automatically once the pause runs over.
+func (s *Service) Pause() {
 	s.mu.Lock()
+	// Already paused or stopped: leave gen alone, or an armed pause limit
+	// would go stale and never fire.
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
 	if s.cancel != nil {
 		s.cancel()
+		s.cancel = nil
 	}
-	s.profileID = profileID
-	s.totalSec = durationSec
-	s.remainSec = durationSec
+	s.gen++
+	s.running = false
+	s.pauseCount++
+	s.pausedAt = time.Now()
+	if s.maxPauseSec > 0 {
+		gen := s.gen
+		s.pauseTimer = time.AfterFunc(time.Duration(s.maxPauseSec)*s.tick, func() {
+			s.pauseExpired(gen)
+		})
+	}
+	_ = s.persistence.Save(s.snapshotLocked())
+	payload := s.pausePayloadLocked()
+	s.mu.Unlock()
+	s.emitter.Emit("timerPaused", payload)
+}
+
+// Unpause continues a paused session from where it stopped.
+func (s *Service) Unpause() {
+	s.mu.Lock()
+	if s.running || s.pausedAt.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.endPauseLocked()
 	s.running = true
+	s.gen++
+	gen := s.gen
 	ctx, cancel := context.WithCancel(context.Background())
 	s.cancel = cancel
+	payload := s.pausePayloadLocked()
 	s.mu.Unlock()
 
-	go s.run(ctx)
+	s.emitter.Emit("timerUnpaused", payload)
+	go s.run(ctx, gen)
 }
 
-// Resume restarts the timer from a previously saved state.
-func (s *Service) Resume(state domain.SessionState) {
+// Checkpoint saves a running or paused session now, e.g. as the app quits.
+func (s *Service) Checkpoint() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running || !s.pausedAt.IsZero() {
+		_ = s.persistence.Save(s.snapshotLocked())
+	}
+}
+
+// Stop halts the timer, rewinds to the first segment and clears persisted state.
+func (s *Service) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopLocked()
+}
+
+// SkipSegment ends the current segment immediately and moves on to the next one,
+// which runs even if the session was paused. Skipping the last segment completes
+// the session.
+func (s *Service) SkipSegment() {
 	s.mu.Lock()
+	paused := !s.running && !s.pausedAt.IsZero()
+	if !paused && (!s.running || s.cancel == nil) {
+		s.mu.Unlock()
+		return
+	}
 	if s.cancel != nil {
 		s.cancel()
 	}
-	s.profileID = state.ProfileID
-	s.totalSec = state.TotalSec
-	s.remainSec = state.RemainingSec
+	s.endPauseLocked()
 	s.running = true
+	s.gen++
+	gen := s.gen
 	ctx, cancel := context.WithCancel(context.Background())
 	s.cancel = cancel
 	s.mu.Unlock()
 
-	go s.run(ctx)
+	if s.advance(gen) {
+		go s.run(ctx, gen)
+	} else {
+		cancel()
+	}
 }
 
-// Pause stops the tick loop while preserving remaining time.
-func (s *Service) Pause() {
+// Active reports whether a session is running or paused.
+func (s *Service) Active() bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if s.cancel != nil {
-		s.cancel()
-		s.cancel = nil
-	}
-	s.running = false
+	return s.running || !s.pausedAt.IsZero()
 }
 
-// Stop halts the timer and clears persisted state.
-func (s *Service) Stop() {
+// CurrentSegment returns the running profile ID and its active segment.
+// ok is false when no session has been started.
+func (s *Service) CurrentSegment() (profileID string, seg domain.Segment, ok bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if s.cancel != nil {
-		s.cancel()
-		s.cancel = nil
+	if s.segIdx >= len(s.segments) {
+		return s.profileID, domain.Segment{}, false
 	}
-	s.running = false
-	s.remainSec = s.totalSec
-	s.persistence.Clear()
+	return s.profileID, s.segments[s.segIdx], true
 }
 
 // GetState returns a current snapshot safe to send to the frontend.
 func (s *Service) GetState() map[string]interface{} {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	return map[string]interface{}{
+	state := map[string]interface{}{
 		"running":      s.running,
+		"paused":       !s.pausedAt.IsZero(),
 		"remainingSec": s.remainSec,
 		"totalSec":     s.totalSec,
 		"profileId":    s.profileID,
+		"segmentIndex": s.segIdx,
+		"segmentCount": len(s.segments),
+		"pauseCount":   s.pauseCount,
+		"pausedSec":    s.pausedSecLocked(),
 	}
+	if s.segIdx < len(s.segments) {
+		state["segmentName"] = s.segments[s.segIdx].Name
+		state["segmentKind"] = s.segments[s.segIdx].Kind
+	}
+	return state
 }
 
 // ── internal ─────────────────────────────────────────────────────────────────
 
-func (s *Service) run(ctx context.Context) {
-	ticker := time.NewTicker(time.Second)
-	autosave := time.NewTicker(60 * time.Second)
+func singleSegment(durationSec int) domain.Segment {
+	return domain.Segment{Name: "Work", DurationSec: durationSec, Kind: domain.SegmentWork}
+}
+
+// begin replaces any current session with state and starts ticking.
+// state.Segments must be non-empty and SegmentIndex in range.
+func (s *Service) begin(state domain.SessionState) {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.endPauseLocked()
+	s.profileID = state.ProfileID
+	s.segments = state.Segments
+	s.segIdx = state.SegmentIndex
+	s.totalSec = s.segments[s.segIdx].DurationSec
+	s.remainSec = state.RemainingSec
+	s.pauseCount = state.PauseCount
+	s.pausedSec = state.PausedSec
+	s.maxPauseSec = state.MaxPauseSec
+	s.pauseAction = state.PauseAction
+	s.warningSec = state.WarningSec
+	s.warningCue = state.WarningCue
+	s.running = true
+	s.gen++
+	gen := s.gen
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.mu.Unlock()
+
+	s.emitSegmentStarted()
+	go s.run(ctx, gen)
+}
+
+// snapshotLocked builds the persisted form of the session. Must be called with s.mu held.
+func (s *Service) snapshotLocked() domain.SessionState {
+	return domain.SessionState{
+		ProfileID:    s.profileID,
+		TotalSec:     s.totalSec,
+		RemainingSec: s.remainSec,
+		Segments:     s.segments,
+		SegmentIndex: s.segIdx,
+		PauseCount:   s.pauseCount,
+		PausedSec:    s.pausedSecLocked(),
+		MaxPauseSec:  s.maxPauseSec,
+		PauseAction:  s.pauseAction,
+		WarningSec:   s.warningSec,
+		WarningCue:   s.warningCue,
+	}
+}
+
+// stopLocked halts the session and rewinds it. Must be called with s.mu held.
+func (s *Service) stopLocked() {
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+	s.gen++
+	s.running = false
+	s.endPauseLocked()
+	s.pauseCount = 0
+	s.pausedSec = 0
+	if len(s.segments) > 0 {
+		s.segIdx = 0
+		s.totalSec = s.segments[0].DurationSec
+	}
+	s.remainSec = s.totalSec
+	s.persistence.Clear()
+}
+
+// pausedSecLocked is the session's total paused time including any pause in progress.
+func (s *Service) pausedSecLocked() int {
+	if s.pausedAt.IsZero() {
+		return s.pausedSec
+	}
+	return s.pausedSec + int(time.Since(s.pausedAt)/s.tick)
+}
+
+// endPauseLocked folds the current pause (if any) into pausedSec and disarms the limit.
+func (s *Service) endPauseLocked() {
+	if s.pauseTimer != nil {
+		s.pauseTimer.Stop()
+		s.pauseTimer = nil
+	}
+	if !s.pausedAt.IsZero() {
+		s.pausedSec = s.pausedSecLocked()
+		s.pausedAt = time.Time{}
+	}
+}
+
+func (s *Service) pausePayloadLocked() map[string]interface{} {
+	return map[string]interface{}{
+		"profileId":    s.profileID,
+		"remainingSec": s.remainSec,
+		"pauseCount":   s.pauseCount,
+		"pausedSec":    s.pausedSecLocked(),
+		"maxPauseSec":  s.maxPauseSec,
+	}
+}
+
+// warningPayloadLocked returns the timerWarning payload when remainSec has just
+// reached one of the profile's thresholds, nil otherwise. Must be called with s.mu held.
+func (s *Service) warningPayloadLocked() map[string]interface{} {
+	for _, th := range s.warningSec {
+		if th > 0 && th == s.remainSec && th < s.totalSec {
+			seg := s.segments[s.segIdx]
+			return map[string]interface{}{
+				"profileId":    s.profileID,
+				"remainingSec": s.remainSec,
+				"thresholdSec": th,
+				"segmentName":  seg.Name,
+				"segmentKind":  seg.Kind,
+				"cue":          s.warningCue,
+			}
+		}
+	}
+	return nil
+}
+
+// pauseExpired applies the profile's PauseAction once a pause exceeds MaxPauseSec.
+func (s *Service) pauseExpired(gen uint64) {
+	s.mu.Lock()
+	if gen != s.gen || s.pausedAt.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	if s.pauseAction == domain.PauseResume {
+		s.mu.Unlock()
+		s.Unpause()
+		return
+	}
+	payload := s.pausePayloadLocked()
+	s.stopLocked()
+	s.mu.Unlock()
+	s.emitter.Emit("timerAbandoned", payload)
+}
+
+// advance finishes the current segment and either moves to the next one
+// (returns true) or completes the session (returns false). A stale gen is a no-op.
+func (s *Service) advance(gen uint64) bool {
+	s.mu.Lock()
+	if gen != s.gen {
+		s.mu.Unlock()
+		return false
+	}
+	finished := s.segments[s.segIdx]
+	profileID := s.profileID
+	index := s.segIdx
+	next := s.segIdx+1 < len(s.segments)
+	pauseCount, pausedSec := s.pauseCount, s.pausedSecLocked()
+	hasWork := false
+	for _, seg := range s.segments {
+		hasWork = hasWork || seg.Kind == domain.SegmentWork
+	}
+	if next {
+		s.segIdx++
+		s.totalSec = s.segments[s.segIdx].DurationSec
+		s.remainSec = s.totalSec
+		_ = s.persistence.Save(s.snapshotLocked())
+	} else {
+		s.running = false
+		s.cancel = nil
+	}
+	s.mu.Unlock()
+
+	s.emitter.Emit("timerSegmentCompleted", map[string]interface{}{
+		"profileId":    profileID,
+		"segmentIndex": index,
+		"segmentName":  finished.Name,
+		"segmentKind":  finished.Kind,
+	})
+	if next {
+		s.emitSegmentStarted()
+		return true
+	}
+	s.persistence.Clear()
+	s.emitter.Emit("timerCompleted", map[string]interface{}{
+		"profileId":  profileID,
+		"pauseCount": pauseCount,
+		"pausedSec":  pausedSec,
+		"hasWork":    hasWork, // the session counts towards the stats
+	})
+	return false
+}
+
+func (s *Service) emitSegmentStarted() {
+	s.mu.Lock()
+	seg := s.segments[s.segIdx]
+	payload := map[string]interface{}{
+		"profileId":    s.profileID,
+		"segmentIndex": s.segIdx,
+		"segmentCount": len(s.segments),
+		"segmentName":  seg.Name,
+		"segmentKind":  seg.Kind,
+		"totalSec":     s.totalSec,
+		"remainingSec": s.remainSec,
+	}
+	s.mu.Unlock()
+	s.emitter.Emit("timerSegmentStarted", payload)
+}
+
+func (s *Service) run(ctx context.Context, gen uint64) {
+	ticker := time.NewTicker(s.tick)
+	autosave := time.NewTicker(60 * s.tick)
 	defer ticker.Stop()
 	defer autosave.Stop()
 
@@ -123,33 +449,33 @@ func (s *Service) run(ctx context.Context) {
 
 		case <-autosave.C:
 			s.mu.Lock()
-			_ = s.persistence.Save(domain.SessionState{
-				ProfileID:    s.profileID,
-				TotalSec:     s.totalSec,
-				RemainingSec: s.remainSec,
-			})
+			_ = s.persistence.Save(s.snapshotLocked())
 			s.mu.Unlock()
 
 		case <-ticker.C:
 			s.mu.Lock()
+			if gen != s.gen {
+				s.mu.Unlock()
+				return
+			}
 			if s.remainSec > 0 {
 				s.remainSec--
 				remaining := s.remainSec
 				profileID := s.profileID
+				warning := s.warningPayloadLocked()
 				s.mu.Unlock()
 				s.emitter.Emit("timerTicked", map[string]interface{}{
 					"remainingSec": remaining,
 					"profileId":    profileID,
 				})
+				if warning != nil {
+					s.emitter.Emit("timerWarning", warning)
+				}
 			} else {
-				s.running = false
-				profileID := s.profileID
 				s.mu.Unlock()
-				s.persistence.Clear()
-				s.emitter.Emit("timerCompleted", map[string]interface{}{
-					"profileId": profileID,
-				})
-				return
+				if !s.advance(gen) {
+					return
+				}
 			}
 		}
 	}
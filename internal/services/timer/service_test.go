@@ -1,6 +1,8 @@
 package timer
 
 import (
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -98,3 +100,324 @@ func TestTimerSecondStartOverridesFirst(t *testing.T) {
 		t.Errorf("totalSec: want 120, got %v", state["totalSec"])
 	}
 }
+
+// recorder is an events.Emitter that keeps every emitted event name in order.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) Emit(event string, _ any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) count(event string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, e := range r.events {
+		if e == event {
+			n++
+		}
+	}
+	return n
+}
+
+func TestProfilePlanLegacyWorkBreak(t *testing.T) {
+	p := domain.Profile{ID: "pomo", DurationSec: 1500, MusicPath: "/music", Shuffle: true, BreakDurationSec: 300}
+	plan := p.Plan()
+	if len(plan) != 2 {
+		t.Fatalf("plan length: want 2, got %d", len(plan))
+	}
+	if plan[1].Kind != domain.SegmentBreak || plan[1].DurationSec != 300 {
+		t.Errorf("second segment: want 300s break, got %+v", plan[1])
+	}
+	if plan[1].MusicPath != "/music" || !plan[1].Shuffle {
+		t.Errorf("break music should fall back to work music, got %+v", plan[1])
+	}
+
+	p.BreakMusicPath = domain.NoMusic
+	if got := p.Plan()[1].MusicPath; got != "" {
+		t.Errorf("NoMusic break: want silent, got %q", got)
+	}
+}
+
+func TestProfilePlanCarriesPhaseVolumes(t *testing.T) {
+	p := domain.Profile{ID: "quiet", DurationSec: 1500, BreakDurationSec: 300, WorkVolume: 40, BreakVolume: 90}
+	if plan := p.Plan(); plan[0].Volume != 40 || plan[1].Volume != 90 {
+		t.Errorf("want work 40 and break 90, got %d and %d", plan[0].Volume, plan[1].Volume)
+	}
+}
+
+func TestProfilePlanCarriesPhaseTone(t *testing.T) {
+	soft := domain.EQ{LowPassHz: 6000, TrebleDB: -4}
+	p := domain.Profile{ID: "soft", DurationSec: 1500, BreakDurationSec: 300, WorkEQ: soft}
+	if plan := p.Plan(); plan[0].EQ != soft || plan[1].EQ != (domain.EQ{}) {
+		t.Errorf("want a soft work phase and a full break, got %+v and %+v", plan[0].EQ, plan[1].EQ)
+	}
+}
+
+func TestProfilePlanExpandsRepeats(t *testing.T) {
+	p := domain.Profile{ID: "seq", Segments: []domain.Segment{
+		{Name: "Warm-up", DurationSec: 600, Kind: domain.SegmentWork},
+		{Name: "Focus", DurationSec: 2700, Kind: domain.SegmentWork, Repeat: 3, RepeatSpan: 2},
+		{Name: "Rest", DurationSec: 600, Kind: domain.SegmentBreak},
+		{Name: "Review", DurationSec: 1200, Kind: domain.SegmentWork},
+	}}
+	var names []string
+	for _, seg := range p.Plan() {
+		names = append(names, seg.Name)
+	}
+	want := []string{"Warm-up", "Focus", "Rest", "Focus", "Rest", "Focus", "Rest", "Review"}
+	if len(names) != len(want) {
+		t.Fatalf("plan: want %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("plan: want %v, got %v", want, names)
+		}
+	}
+}
+
+func TestTimerStartProfileRunsSequence(t *testing.T) {
+	svc := newTestTimer(t)
+	svc.tick = 5 * time.Millisecond
+	rec := &recorder{}
+	svc.SetEmitter(rec)
+
+	svc.StartProfile(domain.Profile{ID: "seq", Segments: []domain.Segment{
+		{Name: "Work", DurationSec: 2, Kind: domain.SegmentWork, Repeat: 2, RepeatSpan: 2},
+		{Name: "Break", DurationSec: 1, Kind: domain.SegmentBreak},
+	}})
+	if got := svc.GetState()["segmentCount"].(int); got != 4 {
+		t.Fatalf("segmentCount: want 4, got %d", got)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for rec.count("timerCompleted") == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if rec.count("timerCompleted") != 1 {
+		t.Fatal("sequence never completed")
+	}
+	if got := rec.count("timerSegmentStarted"); got != 4 {
+		t.Errorf("timerSegmentStarted: want 4, got %d", got)
+	}
+	if got := rec.count("timerSegmentCompleted"); got != 4 {
+		t.Errorf("timerSegmentCompleted: want 4, got %d", got)
+	}
+	if svc.GetState()["running"].(bool) {
+		t.Error("Timer should stop after the last segment")
+	}
+}
+
+func TestTimerStartProfileRejectsEmptyPlan(t *testing.T) {
+	svc := newTestTimer(t)
+	rec := &recorder{}
+	svc.SetEmitter(rec)
+
+	err := svc.StartProfile(domain.Profile{ID: "empty", Segments: []domain.Segment{
+		{Name: "Work", DurationSec: 0, Kind: domain.SegmentWork},
+		{Name: "Break", DurationSec: -5, Kind: domain.SegmentBreak},
+	}})
+	if !errors.Is(err, ErrEmptyPlan) {
+		t.Errorf("want ErrEmptyPlan, got %v", err)
+	}
+	if svc.GetState()["running"].(bool) || rec.count("timerSegmentStarted") != 0 {
+		t.Error("an empty plan must not start the timer")
+	}
+}
+
+func TestTimerSkipSegment(t *testing.T) {
+	svc := newTestTimer(t)
+	svc.StartProfile(domain.Profile{ID: "pomo", DurationSec: 1500, BreakDurationSec: 300})
+
+	svc.SkipSegment()
+	state := svc.GetState()
+	if state["segmentIndex"].(int) != 1 {
+		t.Errorf("segmentIndex after skip: want 1, got %v", state["segmentIndex"])
+	}
+	if state["totalSec"].(int) != 300 || state["remainingSec"].(int) != 300 {
+		t.Errorf("break segment: want 300/300, got %v/%v", state["totalSec"], state["remainingSec"])
+	}
+
+	svc.SkipSegment()
+	if svc.GetState()["running"].(bool) {
+		t.Error("Skipping the last segment should complete the session")
+	}
+}
+
+func TestTimerSkipWhilePaused(t *testing.T) {
+	svc := newTestTimer(t)
+	svc.StartProfile(domain.Profile{ID: "pomo", DurationSec: 1500, BreakDurationSec: 300})
+	svc.Pause()
+
+	svc.SkipSegment()
+	state := svc.GetState()
+	if state["segmentIndex"].(int) != 1 || !state["running"].(bool) {
+		t.Errorf("skip while paused: want the break running, got %v", state)
+	}
+	svc.Pause()
+	svc.SkipSegment()
+	if svc.GetState()["running"].(bool) {
+		t.Error("Skipping the last segment while paused should complete the session")
+	}
+}
+
+func TestTimerPersistsMidSequence(t *testing.T) {
+	ps := persistence.New(t.TempDir())
+	svc := New(ps)
+	svc.StartProfile(domain.Profile{ID: "pomo", DurationSec: 1500, BreakDurationSec: 300})
+	svc.SkipSegment()
+	svc.Pause()
+
+	saved := ps.Load()
+	if saved == nil {
+		t.Fatal("state.json not written on segment change")
+	}
+	if saved.SegmentIndex != 1 || len(saved.Segments) != 2 {
+		t.Fatalf("saved plan: want index 1 of 2, got %d of %d", saved.SegmentIndex, len(saved.Segments))
+	}
+
+	resumed := New(persistence.New(t.TempDir()))
+	resumed.Resume(*saved)
+	_, seg, ok := resumed.CurrentSegment()
+	if !ok || seg.Kind != domain.SegmentBreak {
+		t.Errorf("Resume should continue in the break segment, got %+v", seg)
+	}
+}
+
+func TestTimerCheckpoint(t *testing.T) {
+	ps := persistence.New(t.TempDir())
+	svc := New(ps)
+	svc.tick = 10 * time.Millisecond
+	svc.Start("p", 60)
+	time.Sleep(35 * time.Millisecond)
+	svc.Checkpoint()
+
+	saved := ps.Load()
+	if saved == nil || saved.RemainingSec >= 60 {
+		t.Fatalf("Checkpoint should save the running session's progress, got %+v", saved)
+	}
+
+	svc.Stop()
+	svc.Checkpoint()
+	if ps.Load() != nil {
+		t.Error("Checkpoint should not save a stopped session")
+	}
+}
+
+func TestTimerPauseAccounting(t *testing.T) {
+	svc := newTestTimer(t)
+	svc.tick = 10 * time.Millisecond
+	svc.Start("p", 600)
+
+	svc.Pause()
+	time.Sleep(55 * time.Millisecond)
+	svc.Pause() // already paused — must not count twice
+	svc.Unpause()
+	svc.Pause()
+	svc.Unpause()
+
+	state := svc.GetState()
+	if state["pauseCount"].(int) != 2 {
+		t.Errorf("pauseCount: want 2, got %v", state["pauseCount"])
+	}
+	if state["pausedSec"].(int) < 5 {
+		t.Errorf("pausedSec: want >= 5, got %v", state["pausedSec"])
+	}
+	if !state["running"].(bool) {
+		t.Error("Timer should be running after Unpause")
+	}
+}
+
+func TestTimerPauseDataPersisted(t *testing.T) {
+	ps := persistence.New(t.TempDir())
+	svc := New(ps)
+	svc.Start("p", 600)
+	svc.Pause()
+
+	saved := ps.Load()
+	if saved == nil || saved.PauseCount != 1 {
+		t.Fatalf("persisted pauseCount: want 1, got %+v", saved)
+	}
+
+	resumed := New(persistence.New(t.TempDir()))
+	resumed.Resume(*saved)
+	if resumed.GetState()["pauseCount"].(int) != 1 {
+		t.Error("Resume should carry pause count over")
+	}
+}
+
+func TestTimerPauseLimitAbandons(t *testing.T) {
+	svc := newTestTimer(t)
+	svc.tick = 5 * time.Millisecond
+	rec := &recorder{}
+	svc.SetEmitter(rec)
+	svc.StartProfile(domain.Profile{ID: "p", DurationSec: 600, MaxPauseSec: 4, PauseAction: domain.PauseAbandon})
+
+	svc.Pause()
+	time.Sleep(100 * time.Millisecond)
+	if rec.count("timerAbandoned") != 1 {
+		t.Fatal("expected timerAbandoned after pause limit")
+	}
+	state := svc.GetState()
+	if state["running"].(bool) || state["paused"].(bool) {
+		t.Error("Abandoned session should be neither running nor paused")
+	}
+	if state["remainingSec"].(int) != 600 {
+		t.Errorf("remainingSec after abandon: want 600, got %v", state["remainingSec"])
+	}
+}
+
+func TestTimerPauseLimitSurvivesSecondPause(t *testing.T) {
+	svc := newTestTimer(t)
+	svc.tick = 5 * time.Millisecond
+	rec := &recorder{}
+	svc.SetEmitter(rec)
+	svc.StartProfile(domain.Profile{ID: "p", DurationSec: 600, MaxPauseSec: 4, PauseAction: domain.PauseAbandon})
+
+	svc.Pause()
+	svc.Pause()
+	time.Sleep(100 * time.Millisecond)
+	if rec.count("timerAbandoned") != 1 {
+		t.Fatal("a second Pause must not disarm the pause limit")
+	}
+	if got := rec.count("timerPaused"); got != 1 {
+		t.Errorf("the second Pause should do nothing, got %d timerPaused", got)
+	}
+}
+
+func TestTimerPauseLimitAutoResumes(t *testing.T) {
+	svc := newTestTimer(t)
+	svc.tick = 5 * time.Millisecond
+	svc.StartProfile(domain.Profile{ID: "p", DurationSec: 600, MaxPauseSec: 4, PauseAction: domain.PauseResume})
+
+	svc.Pause()
+	time.Sleep(100 * time.Millisecond)
+	if !svc.GetState()["running"].(bool) {
+		t.Error("Timer should auto-resume after pause limit")
+	}
+	if svc.GetState()["pausedSec"].(int) < 4 {
+		t.Errorf("pausedSec: want >= 4, got %v", svc.GetState()["pausedSec"])
+	}
+}
+
+func TestTimerWarningThresholds(t *testing.T) {
+	svc := newTestTimer(t)
+	svc.tick = 5 * time.Millisecond
+	rec := &recorder{}
+	svc.SetEmitter(rec)
+	svc.StartProfile(domain.Profile{ID: "p", DurationSec: 10, WarningSec: []int{5, 2, 30}})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for rec.count("timerCompleted") == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	// 30 s exceeds the 10 s segment and must never fire
+	if got := rec.count("timerWarning"); got != 2 {
+		t.Errorf("timerWarning: want 2, got %d", got)
+	}
+}